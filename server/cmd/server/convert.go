@@ -0,0 +1,85 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/ApexCorse/ephoros/server/internal/config"
+)
+
+func runConvert(args []string) int {
+	var inPath, outPath, from, to string
+
+	fs := flag.NewFlagSet("convert", flag.ExitOnError)
+	fs.StringVar(&inPath, "in", "", "path of the configuration to convert")
+	fs.StringVar(&outPath, "out", "-", "path of the converted configuration, - for stdout")
+	fs.StringVar(&from, "from", "", "format of the input: json, yaml or toml (default: from extension or content)")
+	fs.StringVar(&to, "to", "", "format of the output: json, yaml or toml (default: from the output extension)")
+	fs.Parse(args)
+
+	if inPath == "" {
+		fmt.Fprintln(os.Stderr, "missing -in")
+		return exitError
+	}
+
+	content, err := os.ReadFile(inPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cannot read configuration: %v\n", err)
+		return exitError
+	}
+
+	inFormat, err := resolveFormat(from, inPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return exitError
+	}
+	if inFormat == "" {
+		inFormat = config.DetectFormat(content)
+	}
+
+	outFormat, err := resolveFormat(to, outPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return exitError
+	}
+	if outFormat == "" {
+		fmt.Fprintln(os.Stderr, "cannot infer the output format, use -to")
+		return exitError
+	}
+
+	cfg, err := config.DecodeConfig(content, inFormat)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cannot decode configuration: %v\n", err)
+		return exitError
+	}
+
+	out := os.Stdout
+	if outPath != "-" {
+		out, err = os.Create(outPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "cannot create %s: %v\n", outPath, err)
+			return exitError
+		}
+		defer out.Close()
+	}
+
+	if err := cfg.Encode(out, outFormat); err != nil {
+		fmt.Fprintf(os.Stderr, "cannot encode configuration: %v\n", err)
+		return exitError
+	}
+
+	return exitOK
+}
+
+func resolveFormat(flagValue, path string) (config.Format, error) {
+	if flagValue != "" {
+		return config.ParseFormat(flagValue)
+	}
+
+	if format, ok := config.FormatFromPath(path); ok {
+		return format, nil
+	}
+
+	return "", nil
+}
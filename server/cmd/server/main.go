@@ -0,0 +1,470 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/ApexCorse/ephoros/server/internal/alert"
+	"github.com/ApexCorse/ephoros/server/internal/api"
+	"github.com/ApexCorse/ephoros/server/internal/bus"
+	"github.com/ApexCorse/ephoros/server/internal/config"
+	"github.com/ApexCorse/ephoros/server/internal/db"
+	"github.com/ApexCorse/ephoros/server/internal/latest"
+	"github.com/ApexCorse/ephoros/server/internal/mqtt"
+	"github.com/ApexCorse/ephoros/server/internal/stream"
+	"github.com/gorilla/mux"
+	mochi "github.com/mochi-mqtt/server/v2"
+	"github.com/mochi-mqtt/server/v2/listeners"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+type options struct {
+	configPath         string
+	configPollInterval time.Duration
+	dbURL              string
+	apiAddress         string
+	mqttAddress        string
+	shutdownTimeout    time.Duration
+
+	batchSize      int
+	flushInterval  time.Duration
+	queueSize      int
+	enqueueTimeout time.Duration
+	flushRetries   int
+	retryBackoff   time.Duration
+
+	chunkInterval time.Duration
+	compressAfter time.Duration
+	retainFor     time.Duration
+
+	secondRollupWindow time.Duration
+	minuteRollupWindow time.Duration
+
+	busQueueSize     int
+	streamBufferSize int
+
+	alertCheckInterval    time.Duration
+	latestPublishInterval time.Duration
+}
+
+func main() {
+	command, args := "serve", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
+	}
+
+	switch command {
+	case "serve":
+		if err := run(parseOptions(args)); err != nil {
+			log.Fatalf("[SERVER] %v", err)
+		}
+	case "plan":
+		os.Exit(runPlan(args))
+	case "validate":
+		os.Exit(runValidate(args))
+	case "convert":
+		os.Exit(runConvert(args))
+	case "hash-password":
+		os.Exit(runHashPassword(args))
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q, expected one of: serve, plan, validate, convert, hash-password\n", command)
+		os.Exit(1)
+	}
+}
+
+func parseOptions(args []string) *options {
+	opts := &options{}
+
+	fs := flag.NewFlagSet("server", flag.ExitOnError)
+	fs.StringVar(&opts.configPath, "config", envOrDefault("CONFIG_PATH", "config.json"),
+		"path to the sensor configuration file (env CONFIG_PATH)")
+	fs.DurationVar(&opts.configPollInterval, "config-poll-interval", durationEnvOrDefault("CONFIG_POLL_INTERVAL", 2*time.Second),
+		"how often the configuration file is checked for changes, 0 disables polling (env CONFIG_POLL_INTERVAL)")
+	fs.StringVar(&opts.dbURL, "db-url", envOrDefault("DB_URL", ""),
+		"Postgres connection string (env DB_URL)")
+	fs.StringVar(&opts.apiAddress, "api-address", envOrDefault("API_ADDRESS", ":8080"),
+		"address the HTTP API listens on (env API_ADDRESS)")
+	fs.StringVar(&opts.mqttAddress, "mqtt-address", envOrDefault("MQTT_ADDRESS", ":1883"),
+		"address of the plaintext MQTT listener, empty disables it (env MQTT_ADDRESS)")
+	fs.DurationVar(&opts.shutdownTimeout, "shutdown-timeout", durationEnvOrDefault("SHUTDOWN_TIMEOUT", 10*time.Second),
+		"time allowed for draining connections on shutdown (env SHUTDOWN_TIMEOUT)")
+	fs.IntVar(&opts.batchSize, "batch-size", intEnvOrDefault("RECORD_BATCH_SIZE", 500),
+		"number of records inserted per batch (env RECORD_BATCH_SIZE)")
+	fs.DurationVar(&opts.flushInterval, "flush-interval", durationEnvOrDefault("RECORD_FLUSH_INTERVAL", time.Second),
+		"maximum time a record waits before being flushed (env RECORD_FLUSH_INTERVAL)")
+	fs.IntVar(&opts.queueSize, "queue-size", intEnvOrDefault("RECORD_QUEUE_SIZE", 10000),
+		"maximum number of records buffered in memory (env RECORD_QUEUE_SIZE)")
+	fs.DurationVar(&opts.enqueueTimeout, "enqueue-timeout", durationEnvOrDefault("RECORD_ENQUEUE_TIMEOUT", 50*time.Millisecond),
+		"time a publish may block on a full queue before the record is dropped (env RECORD_ENQUEUE_TIMEOUT)")
+	fs.IntVar(&opts.flushRetries, "flush-retries", intEnvOrDefault("RECORD_FLUSH_RETRIES", 3),
+		"number of times a failed batch is retried before its records are dropped (env RECORD_FLUSH_RETRIES)")
+	fs.DurationVar(&opts.retryBackoff, "retry-backoff", durationEnvOrDefault("RECORD_RETRY_BACKOFF", 100*time.Millisecond),
+		"delay before the first flush retry, doubled on every attempt (env RECORD_RETRY_BACKOFF)")
+	fs.DurationVar(&opts.chunkInterval, "chunk-interval", durationEnvOrDefault("RECORD_CHUNK_INTERVAL", 0),
+		"time range covered by each records chunk, 0 keeps the current interval (env RECORD_CHUNK_INTERVAL)")
+	fs.DurationVar(&opts.compressAfter, "compress-after", durationEnvOrDefault("RECORD_COMPRESS_AFTER", 7*24*time.Hour),
+		"age after which records chunks are compressed, 0 disables compression (env RECORD_COMPRESS_AFTER)")
+	fs.DurationVar(&opts.retainFor, "retention", durationEnvOrDefault("RECORD_RETENTION", 0),
+		"age after which records are dropped, 0 keeps them forever (env RECORD_RETENTION)")
+	fs.DurationVar(&opts.secondRollupWindow, "rollup-1s-window", durationEnvOrDefault("RECORD_ROLLUP_1S_WINDOW", 6*time.Hour),
+		"how far back the 1s rollup is refreshed, must be shorter than the retention (env RECORD_ROLLUP_1S_WINDOW)")
+	fs.DurationVar(&opts.minuteRollupWindow, "rollup-1m-window", durationEnvOrDefault("RECORD_ROLLUP_1M_WINDOW", 7*24*time.Hour),
+		"how far back the 1m rollup is refreshed, must be shorter than the retention (env RECORD_ROLLUP_1M_WINDOW)")
+	fs.IntVar(&opts.busQueueSize, "bus-queue-size", intEnvOrDefault("BUS_QUEUE_SIZE", 1024),
+		"number of samples buffered per internal bus subscriber (env BUS_QUEUE_SIZE)")
+	fs.IntVar(&opts.streamBufferSize, "stream-buffer-size", intEnvOrDefault("STREAM_BUFFER_SIZE", 256),
+		"number of events buffered per stream client before it is disconnected (env STREAM_BUFFER_SIZE)")
+	fs.DurationVar(&opts.alertCheckInterval, "alert-check-interval", durationEnvOrDefault("ALERT_CHECK_INTERVAL", time.Second),
+		"how often sensors are checked for stale data (env ALERT_CHECK_INTERVAL)")
+	fs.DurationVar(&opts.latestPublishInterval, "latest-publish-interval", durationEnvOrDefault("LATEST_PUBLISH_INTERVAL", 500*time.Millisecond),
+		"minimum time between retained latest-value publishes of a sensor (env LATEST_PUBLISH_INTERVAL)")
+	fs.Parse(args)
+
+	return opts
+}
+
+func run(opts *options) error {
+	log.Printf("[SERVER] Loading configuration from %s", opts.configPath)
+
+	cfg, err := config.NewConfigFromFile(opts.configPath)
+	if err != nil {
+		return fmt.Errorf("cannot load configuration: %w", err)
+	}
+
+	if err := checkMQTTListeners(opts.mqttAddress, cfg.Broker); err != nil {
+		return err
+	}
+
+	gormDB, closeDB, err := openDB(opts.dbURL)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	if err := db.AutoMigrate(gormDB); err != nil {
+		return fmt.Errorf("cannot migrate database: %w", err)
+	}
+
+	storagePolicy := &db.StoragePolicyConfig{
+		ChunkInterval: opts.chunkInterval,
+		CompressAfter: opts.compressAfter,
+		RetainFor:     opts.retainFor,
+
+		SecondRollupWindow: opts.secondRollupWindow,
+		MinuteRollupWindow: opts.minuteRollupWindow,
+	}
+	if err := db.ApplyStoragePolicies(gormDB, storagePolicy); err != nil {
+		return fmt.Errorf("cannot apply storage policies: %w", err)
+	}
+
+	database := db.NewDB(gormDB)
+	database.SetStoragePolicy(storagePolicy)
+
+	cache := db.NewSensorCache(database)
+	if err := cache.Refresh(); err != nil {
+		return fmt.Errorf("cannot load sensor cache: %w", err)
+	}
+
+	manager := config.NewConfigManager(cfg, database)
+	manager.SetSensorCache(cache)
+
+	if err := manager.UpdateDB(); err != nil {
+		return fmt.Errorf("cannot update database from configuration: %w", err)
+	}
+
+	writer := db.NewRecordWriter(database, &db.RecordWriterConfig{
+		BatchSize:      opts.batchSize,
+		FlushInterval:  opts.flushInterval,
+		QueueSize:      opts.queueSize,
+		EnqueueTimeout: opts.enqueueTimeout,
+		FlushRetries:   opts.flushRetries,
+		RetryBackoff:   opts.retryBackoff,
+	})
+
+	hub := stream.NewHub(&stream.HubConfig{
+		BufferSize: opts.streamBufferSize,
+	})
+
+	eventBus := bus.New()
+
+	dataHook := mqtt.NewDataHook(&mqtt.DataHookConfig{
+		Config: cfg,
+		DB:     database,
+		Cache:  cache,
+		Bus:    eventBus,
+	})
+
+	broker := mqtt.NewMQTT(&mqtt.MQTTConfig{
+		Config: cfg,
+		DB:     database,
+		Hooks: []mqtt.HookConfig{
+			{Hook: dataHook},
+		},
+		Listeners: plainListeners(opts.mqttAddress),
+		Server:    mochi.New(&mochi.Options{InlineClient: true}),
+	})
+
+	alerts := alert.NewEngine(&alert.EngineConfig{
+		Config:        cfg,
+		DB:            database,
+		Publisher:     broker,
+		CheckInterval: opts.alertCheckInterval,
+	})
+
+	latestValues := latest.NewStore(&latest.StoreConfig{
+		Config:      cfg,
+		Publisher:   broker,
+		MinInterval: opts.latestPublishInterval,
+	})
+	if err := latestValues.Restore(database); err != nil {
+		log.Printf("[SERVER] Cannot restore latest values, snapshot starts empty: %v", err)
+	}
+
+	if err := subscribeConsumers(eventBus, opts, writer, hub, alerts, latestValues); err != nil {
+		eventBus.Close()
+		writer.Close()
+		return err
+	}
+
+	a := api.NewAPI(&api.APIConfig{
+		Address: opts.apiAddress,
+		Config:  cfg,
+		DB:      database,
+		Router:  mux.NewRouter(),
+		Hub:     hub,
+		Latest:  latestValues,
+		Writer:  writer,
+		Bus:     eventBus,
+	})
+
+	if err := broker.Start(); err != nil {
+		eventBus.Close()
+		writer.Close()
+		return fmt.Errorf("cannot start MQTT broker: %w", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	watcher := config.NewWatcher(&config.WatcherConfig{
+		Path:     opts.configPath,
+		Interval: opts.configPollInterval,
+		Config:   cfg,
+		OnChange: func(newCfg *config.Config) error {
+			if _, err := manager.Reload(newCfg); err != nil {
+				return err
+			}
+
+			dataHook.SetConfig(newCfg)
+			alerts.SetConfig(newCfg)
+			latestValues.SetConfig(newCfg)
+			broker.SetConfig(newCfg)
+			a.SetConfig(newCfg)
+			return nil
+		},
+	})
+	go watcher.Run(ctx)
+	go alerts.Run(ctx)
+	go latestValues.Run(ctx)
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hup:
+				log.Println("[SERVER] SIGHUP received, reloading configuration")
+				watcher.Reload()
+			}
+		}
+	}()
+
+	apiErr := make(chan error, 1)
+	go func() {
+		apiErr <- a.Start()
+	}()
+
+	select {
+	case <-ctx.Done():
+		log.Println("[SERVER] Shutdown signal received")
+	case err = <-apiErr:
+		log.Printf("[SERVER] API server exited: %v", err)
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), opts.shutdownTimeout)
+	defer cancel()
+
+	hub.Close()
+
+	if shutdownErr := a.Shutdown(shutdownCtx); shutdownErr != nil {
+		log.Printf("[SERVER] Error shutting down API server: %v", shutdownErr)
+	}
+
+	if closeErr := broker.Close(); closeErr != nil {
+		log.Printf("[SERVER] Error closing MQTT broker: %v", closeErr)
+	}
+
+	eventBus.Close()
+	writer.Close()
+
+	log.Println("[SERVER] Shutdown complete")
+	return err
+}
+
+func subscribeConsumers(eventBus *bus.Bus, opts *options, writer *db.RecordWriter, hub *stream.Hub, alerts *alert.Engine, latestValues *latest.Store) error {
+	_, err := eventBus.Subscribe(&bus.SubscriberConfig{
+		Name:      "db",
+		QueueSize: opts.busQueueSize,
+		Policy:    bus.PolicyBlock,
+	}, func(sample bus.Sample) {
+		if err := writer.Write(sample.Record); err != nil {
+			log.Printf("[SERVER] Error queueing record for %s: %v", sample.Path(), err)
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("cannot subscribe record writer: %w", err)
+	}
+
+	_, err = eventBus.Subscribe(&bus.SubscriberConfig{
+		Name:      "stream",
+		QueueSize: opts.busQueueSize,
+		Policy:    bus.PolicyDrop,
+	}, func(sample bus.Sample) {
+		hub.Publish(stream.Event{
+			Section: sample.Section,
+			Module:  sample.Module,
+			Sensor:  sample.Sensor,
+			Record:  sample.Record,
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("cannot subscribe stream hub: %w", err)
+	}
+
+	_, err = eventBus.Subscribe(&bus.SubscriberConfig{
+		Name:      "alerts",
+		QueueSize: opts.busQueueSize,
+		Policy:    bus.PolicyDrop,
+	}, alerts.Handle)
+	if err != nil {
+		return fmt.Errorf("cannot subscribe alert engine: %w", err)
+	}
+
+	_, err = eventBus.Subscribe(&bus.SubscriberConfig{
+		Name:      "latest",
+		QueueSize: opts.busQueueSize,
+		Policy:    bus.PolicyDrop,
+	}, latestValues.Handle)
+	if err != nil {
+		return fmt.Errorf("cannot subscribe latest value store: %w", err)
+	}
+
+	return nil
+}
+
+func checkMQTTListeners(plainAddress string, broker *config.BrokerConfig) error {
+	if plainAddress == "" {
+		if broker == nil || len(broker.Listeners()) == 0 {
+			return errors.New("no MQTT listener configured, set an MQTT address or configure broker.tls or broker.websocket")
+		}
+
+		log.Println("[SERVER] Plaintext MQTT listener disabled")
+		return nil
+	}
+
+	if broker == nil {
+		return nil
+	}
+
+	if err := broker.CheckAddress(plainAddress); err != nil {
+		return fmt.Errorf("invalid MQTT address: %w", err)
+	}
+
+	if broker.TLS != nil {
+		log.Printf("[SERVER] Plaintext MQTT listener on %s is enabled alongside TLS, set an empty MQTT address to only accept TLS clients", plainAddress)
+	}
+
+	return nil
+}
+
+func plainListeners(address string) []listeners.Listener {
+	if address == "" {
+		return nil
+	}
+
+	return []listeners.Listener{
+		listeners.NewTCP(listeners.Config{ID: "tcp", Address: address}),
+	}
+}
+
+func openDB(url string) (*gorm.DB, func(), error) {
+	if url == "" {
+		return nil, nil, errors.New("no database URL provided")
+	}
+
+	log.Println("[SERVER] Connecting to database")
+
+	gormDB, err := gorm.Open(postgres.Open(url), &gorm.Config{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot connect to database: %w", err)
+	}
+
+	sqlDB, err := gormDB.DB()
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot access database connection: %w", err)
+	}
+
+	return gormDB, func() { sqlDB.Close() }, nil
+}
+
+func envOrDefault(key, def string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
+	}
+
+	return def
+}
+
+func intEnvOrDefault(key string, def int) int {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return def
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("[SERVER] Invalid integer for %s: %q, using %d", key, value, def)
+		return def
+	}
+
+	return n
+}
+
+func durationEnvOrDefault(key string, def time.Duration) time.Duration {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return def
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("[SERVER] Invalid duration for %s: %q, using %s", key, value, def)
+		return def
+	}
+
+	return d
+}
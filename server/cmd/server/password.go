@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ApexCorse/ephoros/server/internal/password"
+	"golang.org/x/term"
+)
+
+func runHashPassword(args []string) int {
+	var algorithm string
+	var cost int
+
+	fs := flag.NewFlagSet("hash-password", flag.ExitOnError)
+	fs.StringVar(&algorithm, "algorithm", string(password.AlgorithmBcrypt), "hash algorithm: bcrypt or argon2id")
+	fs.IntVar(&cost, "cost", 0, "bcrypt cost or argon2id iterations, 0 for the default")
+	fs.Parse(args)
+
+	plain, err := readPassword()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cannot read password: %v\n", err)
+		return exitError
+	}
+
+	hash, err := password.Hash(plain, password.Algorithm(algorithm), cost)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cannot hash password: %v\n", err)
+		return exitError
+	}
+
+	fmt.Println(hash)
+	return exitOK
+}
+
+func readPassword() (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return "", err
+		}
+
+		return strings.TrimRight(line, "\r\n"), nil
+	}
+
+	fmt.Fprint(os.Stderr, "Password: ")
+	first, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+
+	fmt.Fprint(os.Stderr, "Confirm password: ")
+	second, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+
+	if string(first) != string(second) {
+		return "", errors.New("passwords do not match")
+	}
+
+	return string(first), nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/ApexCorse/ephoros/server/internal/config"
+	"github.com/ApexCorse/ephoros/server/internal/db"
+)
+
+const (
+	exitOK          = 0
+	exitError       = 1
+	exitDestructive = 2
+	exitInvalid     = 3
+)
+
+func runPlan(args []string) int {
+	var configPath, currentPath, dbURL string
+	var asJSON bool
+
+	fs := flag.NewFlagSet("plan", flag.ExitOnError)
+	fs.StringVar(&configPath, "config", envOrDefault("CONFIG_PATH", "config.json"),
+		"path to the configuration to plan (env CONFIG_PATH)")
+	fs.StringVar(&currentPath, "current", "",
+		"path to the currently deployed configuration, used to diff MQTT users, which are not stored in the database")
+	fs.StringVar(&dbURL, "db-url", envOrDefault("DB_URL", ""),
+		"Postgres connection string (env DB_URL)")
+	fs.BoolVar(&asJSON, "json", false, "print the plan as JSON")
+	fs.Parse(args)
+
+	cfg, err := config.NewConfigFromFile(configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cannot load configuration: %v\n", err)
+		return exitError
+	}
+
+	var current *config.Config
+	if currentPath != "" {
+		current, err = config.NewConfigFromFile(currentPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "cannot load current configuration: %v\n", err)
+			return exitError
+		}
+	}
+
+	gormDB, closeDB, err := openDB(dbURL)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return exitError
+	}
+	defer closeDB()
+
+	plan, err := config.NewConfigManager(cfg, db.NewDB(gormDB)).Plan(current)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cannot compute plan: %v\n", err)
+		return exitError
+	}
+
+	if asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(plan)
+	} else {
+		fmt.Print(plan.String())
+	}
+
+	if plan.Destructive() {
+		fmt.Fprintln(os.Stderr, "plan contains destructive changes")
+		return exitDestructive
+	}
+
+	return exitOK
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/ApexCorse/ephoros/server/internal/config"
+)
+
+type validateResult struct {
+	Valid  bool                     `json:"valid"`
+	Errors []config.ValidationError `json:"errors"`
+}
+
+func runValidate(args []string) int {
+	var configPath string
+	var asJSON bool
+
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+	fs.StringVar(&configPath, "config", envOrDefault("CONFIG_PATH", "config.json"),
+		"path to the configuration to validate (env CONFIG_PATH)")
+	fs.BoolVar(&asJSON, "json", false, "print the result as JSON")
+	fs.Parse(args)
+
+	result := &validateResult{Valid: true, Errors: []config.ValidationError{}}
+
+	_, err := config.NewConfigFromFile(configPath)
+	if err != nil {
+		var validationErrs config.ValidationErrors
+		if !errors.As(err, &validationErrs) {
+			fmt.Fprintf(os.Stderr, "cannot load configuration: %v\n", err)
+			return exitError
+		}
+
+		result.Valid = false
+		result.Errors = validationErrs
+	}
+
+	if asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(result)
+	} else if result.Valid {
+		fmt.Printf("%s is valid\n", configPath)
+	} else {
+		fmt.Printf("%s has %d problems:\n", configPath, len(result.Errors))
+		for _, validationErr := range result.Errors {
+			fmt.Printf("  %s\n", validationErr.Error())
+		}
+	}
+
+	if !result.Valid {
+		return exitInvalid
+	}
+
+	return exitOK
+}
@@ -0,0 +1,386 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/ApexCorse/ephoros/server/internal/bus"
+	"github.com/ApexCorse/ephoros/server/internal/config"
+	"github.com/ApexCorse/ephoros/server/internal/db"
+)
+
+const TopicPrefix = config.ReservedSection + "/alerts/"
+
+const (
+	KindMin   = "min"
+	KindMax   = "max"
+	KindRate  = "rate"
+	KindStale = "stale"
+)
+
+const (
+	StateFiring   = "firing"
+	StateResolved = "resolved"
+)
+
+type Publisher interface {
+	Publish(topic string, payload []byte, retain bool, qos byte) error
+}
+
+type Notification struct {
+	State string `json:"state"`
+	db.Alert
+}
+
+type EngineConfig struct {
+	Config        *config.Config
+	DB            *db.DB
+	Publisher     Publisher
+	CheckInterval time.Duration
+}
+
+type series struct {
+	rule    string
+	section string
+	module  string
+	sensor  string
+
+	hasValue  bool
+	lastValue float64
+	lastAt    time.Time
+	lastSeen  time.Time
+
+	active map[string]*db.Alert
+}
+
+type Engine struct {
+	db        *db.DB
+	publisher Publisher
+	interval  time.Duration
+	now       func() time.Time
+
+	mu     sync.Mutex
+	rules  []config.AlertRuleConfig
+	series map[string]*series
+}
+
+func NewEngine(cfg *EngineConfig) *Engine {
+	if cfg.DB == nil {
+		log.Println("[ALERT] db is nil, alerts will not be persisted")
+	}
+
+	if cfg.Publisher == nil {
+		log.Println("[ALERT] publisher is nil, alerts will not be published over MQTT")
+	}
+
+	interval := cfg.CheckInterval
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	e := &Engine{
+		db:        cfg.DB,
+		publisher: cfg.Publisher,
+		interval:  interval,
+		now:       time.Now,
+		series:    make(map[string]*series),
+	}
+
+	e.restore()
+	e.SetConfig(cfg.Config)
+
+	return e
+}
+
+func (e *Engine) SetConfig(cfg *config.Config) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var rules []config.AlertRuleConfig
+	if cfg != nil {
+		rules = slices.Clone(cfg.Alerts)
+	}
+	e.rules = rules
+
+	now := e.now()
+	for key, s := range e.series {
+		rule := e.rule(s.rule)
+		if rule != nil && rule.Matches(s.section, s.module, s.sensor) {
+			e.clearDisabled(rule, s, now)
+			continue
+		}
+
+		for kind := range s.active {
+			e.resolve(s, kind, now)
+		}
+		delete(e.series, key)
+	}
+
+	if cfg != nil {
+		for i := range e.rules {
+			rule := &e.rules[i]
+			if rule.StaleTimeout() <= 0 {
+				continue
+			}
+
+			for _, sConfig := range cfg.SensorConfigs {
+				if rule.Matches(sConfig.Section, sConfig.Module, sConfig.Name) {
+					e.seriesFor(rule.Name, sConfig.Section, sConfig.Module, sConfig.Name, now)
+				}
+			}
+		}
+	}
+
+	log.Printf("[ALERT] Loaded %d alert rules - Tracked series: %d", len(e.rules), len(e.series))
+}
+
+func (e *Engine) Handle(sample bus.Sample) {
+	now := e.now()
+
+	at := sample.Record.CreatedAt
+	if at.IsZero() {
+		at = now
+	}
+	value := sample.Record.Value
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for i := range e.rules {
+		rule := &e.rules[i]
+		if !rule.Matches(sample.Section, sample.Module, sample.Sensor) {
+			continue
+		}
+
+		s := e.seriesFor(rule.Name, sample.Section, sample.Module, sample.Sensor, now)
+		e.resolve(s, KindStale, now)
+
+		if rule.Max != nil {
+			if value > *rule.Max {
+				e.fire(rule, s, KindMax, value, *rule.Max, now)
+			} else if value <= *rule.Max-rule.Hysteresis {
+				e.resolve(s, KindMax, now)
+			}
+		}
+
+		if rule.Min != nil {
+			if value < *rule.Min {
+				e.fire(rule, s, KindMin, value, *rule.Min, now)
+			} else if value >= *rule.Min+rule.Hysteresis {
+				e.resolve(s, KindMin, now)
+			}
+		}
+
+		if rule.MaxRate != nil && s.hasValue {
+			if elapsed := at.Sub(s.lastAt).Seconds(); elapsed > 0 {
+				rate := math.Abs(value-s.lastValue) / elapsed
+				if rate > *rule.MaxRate {
+					e.fire(rule, s, KindRate, rate, *rule.MaxRate, now)
+				} else {
+					e.resolve(s, KindRate, now)
+				}
+			}
+		}
+
+		s.hasValue = true
+		s.lastValue = value
+		s.lastAt = at
+		s.lastSeen = now
+	}
+}
+
+func (e *Engine) Run(ctx context.Context) {
+	log.Printf("[ALERT] Checking for stale sensors every %s", e.interval)
+
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("[ALERT] Alert engine stopped")
+			return
+		case <-ticker.C:
+			e.CheckStale()
+		}
+	}
+}
+
+func (e *Engine) CheckStale() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	now := e.now()
+	for _, s := range e.series {
+		rule := e.rule(s.rule)
+		if rule == nil {
+			continue
+		}
+
+		timeout := rule.StaleTimeout()
+		if timeout <= 0 {
+			continue
+		}
+
+		if silence := now.Sub(s.lastSeen); silence > timeout {
+			e.fire(rule, s, KindStale, silence.Seconds(), timeout.Seconds(), now)
+		}
+	}
+}
+
+func (e *Engine) Active() []db.Alert {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	alerts := make([]db.Alert, 0)
+	for _, s := range e.series {
+		for _, alert := range s.active {
+			alerts = append(alerts, *alert)
+		}
+	}
+
+	slices.SortFunc(alerts, func(a, b db.Alert) int {
+		return a.FiredAt.Compare(b.FiredAt)
+	})
+
+	return alerts
+}
+
+func (e *Engine) restore() {
+	if e.db == nil {
+		return
+	}
+
+	alerts, err := e.db.GetActiveAlerts()
+	if err != nil {
+		log.Printf("[ALERT] Error loading active alerts: %v", err)
+		return
+	}
+
+	now := e.now()
+	for i := range alerts {
+		alert := alerts[i]
+		s := e.seriesFor(alert.Rule, alert.Section, alert.Module, alert.Sensor, now)
+		s.active[alert.Kind] = &alert
+	}
+
+	log.Printf("[ALERT] Restored %d active alerts", len(alerts))
+}
+
+func (e *Engine) rule(name string) *config.AlertRuleConfig {
+	for i := range e.rules {
+		if e.rules[i].Name == name {
+			return &e.rules[i]
+		}
+	}
+
+	return nil
+}
+
+func (e *Engine) seriesFor(rule, section, module, sensor string, now time.Time) *series {
+	key := rule + "\x00" + section + "/" + module + "/" + sensor
+	if s, ok := e.series[key]; ok {
+		return s
+	}
+
+	s := &series{
+		rule:     rule,
+		section:  section,
+		module:   module,
+		sensor:   sensor,
+		lastSeen: now,
+		active:   make(map[string]*db.Alert),
+	}
+	e.series[key] = s
+
+	return s
+}
+
+func (e *Engine) clearDisabled(rule *config.AlertRuleConfig, s *series, now time.Time) {
+	enabled := map[string]bool{
+		KindMin:   rule.Min != nil,
+		KindMax:   rule.Max != nil,
+		KindRate:  rule.MaxRate != nil,
+		KindStale: rule.StaleTimeout() > 0,
+	}
+
+	for kind := range s.active {
+		if !enabled[kind] {
+			e.resolve(s, kind, now)
+		}
+	}
+}
+
+func (e *Engine) fire(rule *config.AlertRuleConfig, s *series, kind string, value, threshold float64, now time.Time) {
+	if _, ok := s.active[kind]; ok {
+		return
+	}
+
+	alert := &db.Alert{
+		Rule:      rule.Name,
+		Kind:      kind,
+		Severity:  rule.SeverityLevel(),
+		Section:   s.section,
+		Module:    s.module,
+		Sensor:    s.sensor,
+		Value:     value,
+		Threshold: threshold,
+		FiredAt:   now,
+	}
+
+	if e.db != nil {
+		if err := e.db.InsertAlert(alert); err != nil {
+			log.Printf("[ALERT] Error persisting alert %s: %v", rule.Name, err)
+		}
+	}
+
+	s.active[kind] = alert
+
+	log.Printf("[ALERT] Alert %s firing - Kind: %s, Sensor: %s/%s/%s, Value: %g, Threshold: %g",
+		rule.Name, kind, s.section, s.module, s.sensor, value, threshold)
+
+	e.notify(StateFiring, alert)
+}
+
+func (e *Engine) resolve(s *series, kind string, now time.Time) {
+	alert, ok := s.active[kind]
+	if !ok {
+		return
+	}
+
+	delete(s.active, kind)
+	resolvedAt := now
+	alert.ResolvedAt = &resolvedAt
+
+	if e.db != nil && alert.ID != 0 {
+		if err := e.db.ResolveAlert(alert.ID, now); err != nil {
+			log.Printf("[ALERT] Error resolving alert %s: %v", alert.Rule, err)
+		}
+	}
+
+	log.Printf("[ALERT] Alert %s resolved - Kind: %s, Sensor: %s/%s/%s",
+		alert.Rule, kind, s.section, s.module, s.sensor)
+
+	e.notify(StateResolved, alert)
+}
+
+func (e *Engine) notify(state string, alert *db.Alert) {
+	if e.publisher == nil {
+		return
+	}
+
+	payload, err := json.Marshal(Notification{State: state, Alert: *alert})
+	if err != nil {
+		log.Printf("[ALERT] Error encoding alert %s: %v", alert.Rule, err)
+		return
+	}
+
+	if err := e.publisher.Publish(TopicPrefix+alert.Rule, payload, false, 1); err != nil {
+		log.Printf("[ALERT] Error publishing alert %s: %v", alert.Rule, err)
+	}
+}
@@ -0,0 +1,231 @@
+package alert
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ApexCorse/ephoros/server/internal/bus"
+	"github.com/ApexCorse/ephoros/server/internal/config"
+	"github.com/ApexCorse/ephoros/server/internal/db"
+	"github.com/stretchr/testify/assert"
+)
+
+type publishedMessage struct {
+	topic        string
+	notification Notification
+}
+
+type fakePublisher struct {
+	mu       sync.Mutex
+	messages []publishedMessage
+}
+
+func (p *fakePublisher) Publish(topic string, payload []byte, retain bool, qos byte) error {
+	notification := Notification{}
+	if err := json.Unmarshal(payload, &notification); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.messages = append(p.messages, publishedMessage{topic: topic, notification: notification})
+	return nil
+}
+
+func (p *fakePublisher) take() []publishedMessage {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	messages := p.messages
+	p.messages = nil
+	return messages
+}
+
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	return c.now
+}
+
+func (c *testClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func floatPtr(v float64) *float64 {
+	return &v
+}
+
+func newTestEngine(cfg *config.Config) (*Engine, *fakePublisher, *testClock) {
+	publisher := &fakePublisher{}
+	clock := &testClock{now: time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)}
+
+	e := NewEngine(&EngineConfig{Publisher: publisher})
+	e.now = clock.Now
+	e.SetConfig(cfg)
+
+	return e, publisher, clock
+}
+
+func sample(section, module, sensor string, value float64, at time.Time) bus.Sample {
+	return bus.Sample{
+		Section: section,
+		Module:  module,
+		Sensor:  sensor,
+		Record:  db.Record{Value: value, CreatedAt: at},
+	}
+}
+
+func TestEngine_MaxWithHysteresis(t *testing.T) {
+	e, publisher, clock := newTestEngine(&config.Config{
+		Alerts: []config.AlertRuleConfig{
+			{Name: "cell-overtemp", Section: "Battery", Module: "Module 1", Max: floatPtr(60), Hysteresis: 2, Severity: config.SeverityCritical},
+		},
+	})
+
+	e.Handle(sample("Battery", "Module 1", "NTC-1", 55, clock.now))
+	assert.Empty(t, publisher.take())
+
+	e.Handle(sample("Battery", "Module 1", "NTC-1", 61, clock.now))
+	e.Handle(sample("Battery", "Module 1", "NTC-1", 62, clock.now))
+	messages := publisher.take()
+	assert.Len(t, messages, 1)
+	assert.Equal(t, "ephoros/alerts/cell-overtemp", messages[0].topic)
+	assert.Equal(t, StateFiring, messages[0].notification.State)
+	assert.Equal(t, KindMax, messages[0].notification.Kind)
+	assert.Equal(t, config.SeverityCritical, messages[0].notification.Severity)
+	assert.Equal(t, "NTC-1", messages[0].notification.Sensor)
+	assert.Equal(t, 61.0, messages[0].notification.Value)
+	assert.Equal(t, 60.0, messages[0].notification.Threshold)
+
+	active := e.Active()
+	assert.Len(t, active, 1)
+	assert.True(t, active[0].Active())
+
+	e.Handle(sample("Battery", "Module 1", "NTC-1", 59, clock.now))
+	assert.Empty(t, publisher.take(), "value within the hysteresis band must not resolve the alert")
+
+	e.Handle(sample("Battery", "Module 1", "NTC-2", 70, clock.now))
+	assert.Len(t, publisher.take(), 1, "module rules track every sensor separately")
+
+	clock.Advance(time.Second)
+	e.Handle(sample("Battery", "Module 1", "NTC-1", 58, clock.now))
+	messages = publisher.take()
+	assert.Len(t, messages, 1)
+	assert.Equal(t, StateResolved, messages[0].notification.State)
+	assert.Equal(t, clock.now, *messages[0].notification.ResolvedAt)
+	assert.Len(t, e.Active(), 1)
+}
+
+func TestEngine_Min(t *testing.T) {
+	e, publisher, clock := newTestEngine(&config.Config{
+		Alerts: []config.AlertRuleConfig{
+			{Name: "pack-undervoltage", Section: "Battery", Module: "Pack", Sensor: "Voltage", Min: floatPtr(300)},
+		},
+	})
+
+	e.Handle(sample("Battery", "Pack", "Current", 10, clock.now))
+	e.Handle(sample("Battery", "Pack", "Voltage", 290, clock.now))
+	messages := publisher.take()
+	assert.Len(t, messages, 1)
+	assert.Equal(t, KindMin, messages[0].notification.Kind)
+	assert.Equal(t, config.SeverityWarning, messages[0].notification.Severity)
+
+	e.Handle(sample("Battery", "Pack", "Voltage", 300, clock.now))
+	messages = publisher.take()
+	assert.Len(t, messages, 1)
+	assert.Equal(t, StateResolved, messages[0].notification.State)
+}
+
+func TestEngine_Rate(t *testing.T) {
+	e, publisher, clock := newTestEngine(&config.Config{
+		Alerts: []config.AlertRuleConfig{
+			{Name: "current-spike", Section: "Battery", Module: "Pack", Sensor: "Current", MaxRate: floatPtr(100)},
+		},
+	})
+
+	start := clock.now
+	e.Handle(sample("Battery", "Pack", "Current", 0, start))
+	e.Handle(sample("Battery", "Pack", "Current", 50, start.Add(time.Second)))
+	assert.Empty(t, publisher.take())
+
+	e.Handle(sample("Battery", "Pack", "Current", 100, start.Add(1100*time.Millisecond)))
+	messages := publisher.take()
+	assert.Len(t, messages, 1)
+	assert.Equal(t, KindRate, messages[0].notification.Kind)
+	assert.InDelta(t, 500, messages[0].notification.Value, 0.001)
+
+	e.Handle(sample("Battery", "Pack", "Current", 110, start.Add(2100*time.Millisecond)))
+	messages = publisher.take()
+	assert.Len(t, messages, 1)
+	assert.Equal(t, StateResolved, messages[0].notification.State)
+}
+
+func TestEngine_Stale(t *testing.T) {
+	cfg := &config.Config{
+		SensorConfigs: []config.SensorConfig{
+			{Name: "Speed", Section: "Cooling", Module: "Pump"},
+			{Name: "Inlet", Section: "Cooling", Module: "Radiator"},
+		},
+		Alerts: []config.AlertRuleConfig{
+			{Name: "pump-silent", Section: "Cooling", Module: "Pump", Stale: "2s"},
+		},
+	}
+	e, publisher, clock := newTestEngine(cfg)
+
+	clock.Advance(time.Second)
+	e.CheckStale()
+	assert.Empty(t, publisher.take())
+
+	clock.Advance(1500 * time.Millisecond)
+	e.CheckStale()
+	e.CheckStale()
+	messages := publisher.take()
+	assert.Len(t, messages, 1, "sensors that never reported are stale too")
+	assert.Equal(t, KindStale, messages[0].notification.Kind)
+	assert.Equal(t, "Speed", messages[0].notification.Sensor)
+	assert.Equal(t, 2.0, messages[0].notification.Threshold)
+
+	e.Handle(sample("Cooling", "Pump", "Speed", 1200, clock.now))
+	messages = publisher.take()
+	assert.Len(t, messages, 1)
+	assert.Equal(t, StateResolved, messages[0].notification.State)
+
+	clock.Advance(time.Second)
+	e.CheckStale()
+	assert.Empty(t, publisher.take())
+}
+
+func TestEngine_SetConfig(t *testing.T) {
+	e, publisher, clock := newTestEngine(&config.Config{
+		Alerts: []config.AlertRuleConfig{
+			{Name: "cell-overtemp", Section: "Battery", Module: "Module 1", Max: floatPtr(60)},
+			{Name: "cell-undervoltage", Section: "Battery", Module: "Module 1", Sensor: "Cell-1", Min: floatPtr(3), Max: floatPtr(4.2)},
+		},
+	})
+
+	e.Handle(sample("Battery", "Module 1", "NTC-1", 65, clock.now))
+	e.Handle(sample("Battery", "Module 1", "Cell-1", 2.5, clock.now))
+	assert.Len(t, publisher.take(), 2)
+
+	e.SetConfig(&config.Config{
+		Alerts: []config.AlertRuleConfig{
+			{Name: "cell-overtemp", Section: "Battery", Module: "Module 1", Max: floatPtr(70)},
+			{Name: "cell-undervoltage", Section: "Battery", Module: "Module 1", Sensor: "Cell-1", Max: floatPtr(4.2)},
+		},
+	})
+	messages := publisher.take()
+	assert.Len(t, messages, 1, "alerts whose condition was removed are resolved")
+	assert.Equal(t, "cell-undervoltage", messages[0].notification.Rule)
+	assert.Equal(t, StateResolved, messages[0].notification.State)
+
+	e.SetConfig(&config.Config{})
+	messages = publisher.take()
+	assert.Len(t, messages, 1, "alerts of removed rules are resolved")
+	assert.Equal(t, "cell-overtemp", messages[0].notification.Rule)
+	assert.Empty(t, e.Active())
+}
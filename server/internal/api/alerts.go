@@ -0,0 +1,80 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+const (
+	defaultAlertsLimit = 100
+	maxAlertsLimit     = 1000
+)
+
+func (a *API) handleAlerts(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[API] Alerts request received from %s", r.RemoteAddr)
+
+	token, err := a.getTokenFromRequest(r)
+	if err != nil {
+		log.Printf("[API] Alerts request failed - token error: %v", err)
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if err := a.validateUser(token); err != nil {
+		log.Printf("[API] Alerts request failed - validation error: %v", err)
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	activeOnly, limit, err := parseAlertsQuery(r)
+	if err != nil {
+		log.Printf("[API] Alerts request failed - invalid query: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	alerts, err := a.db.GetAlerts(activeOnly, limit)
+	if err != nil {
+		log.Printf("[API] Alerts request failed - database error: %v", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("[API] Alerts request successful - found %d alerts (active only: %t)", len(alerts), activeOnly)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	json.NewEncoder(w).Encode(
+		map[string]any{
+			"alerts": alerts,
+		},
+	)
+}
+
+func parseAlertsQuery(r *http.Request) (bool, int, error) {
+	query := r.URL.Query()
+
+	activeOnly := false
+	if value := query.Get("active"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return false, 0, errors.New("invalid active parameter")
+		}
+		activeOnly = parsed
+	}
+
+	limit := defaultAlertsLimit
+	if value := query.Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			return false, 0, errors.New("invalid limit parameter")
+		}
+		limit = min(parsed, maxAlertsLimit)
+	}
+
+	return activeOnly, limit, nil
+}
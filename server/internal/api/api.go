@@ -1,14 +1,20 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"log"
 	"net/http"
 	"strings"
+	"sync/atomic"
+	"time"
 
+	"github.com/ApexCorse/ephoros/server/internal/bus"
 	"github.com/ApexCorse/ephoros/server/internal/config"
 	"github.com/ApexCorse/ephoros/server/internal/db"
+	"github.com/ApexCorse/ephoros/server/internal/latest"
+	"github.com/ApexCorse/ephoros/server/internal/stream"
 	"github.com/gorilla/mux"
 )
 
@@ -17,13 +23,22 @@ type APIConfig struct {
 	Config  *config.Config
 	DB      *db.DB
 	Router  *mux.Router
+	Hub     *stream.Hub
+	Latest  *latest.Store
+	Writer  *db.RecordWriter
+	Bus     *bus.Bus
 }
 
 type API struct {
 	db      *db.DB
 	r       *mux.Router
+	hub     *stream.Hub
+	latest  *latest.Store
+	writer  *db.RecordWriter
+	bus     *bus.Bus
 	address string
-	config  *config.Config
+	config  atomic.Pointer[config.Config]
+	server  *http.Server
 }
 
 func NewAPI(cfg *APIConfig) *API {
@@ -47,31 +62,78 @@ func NewAPI(cfg *APIConfig) *API {
 		log.Println("[API] Router configured")
 	}
 
+	if cfg.Hub == nil {
+		log.Println("[API] stream hub is nil, /stream is disabled")
+	}
+
+	if cfg.Latest == nil {
+		log.Println("[API] latest value store is nil, /snapshot is disabled")
+	}
+
+	if cfg.Writer == nil && cfg.Bus == nil {
+		log.Println("[API] record writer and bus are nil, /stats only reports the stream hub")
+	}
+
 	if cfg.Address == "" {
 		log.Println("[API] address is empty, caution")
 	} else {
 		log.Printf("[API] Server will listen on: %s", cfg.Address)
 	}
 
-	return &API{
+	a := &API{
 		db:      cfg.DB,
 		r:       cfg.Router,
+		hub:     cfg.Hub,
+		latest:  cfg.Latest,
+		writer:  cfg.Writer,
+		bus:     cfg.Bus,
 		address: cfg.Address,
-		config:  cfg.Config,
+		server: &http.Server{
+			Addr:    cfg.Address,
+			Handler: cfg.Router,
+		},
 	}
+	a.config.Store(cfg.Config)
+
+	return a
+}
+
+func (a *API) SetConfig(cfg *config.Config) {
+	log.Println("[API] Configuration updated")
+	a.config.Store(cfg)
 }
 
-func (a *API) Start() {
+func (a *API) Start() error {
 	log.Println("[API] Starting API server")
 
 	a.r.HandleFunc("/auth", a.handleAuth).Methods("POST")
 	a.r.HandleFunc("/data", a.handleSendData).Methods("POST")
+	a.r.HandleFunc("/stream", a.handleStream).Methods("GET")
+	a.r.HandleFunc("/alerts", a.handleAlerts).Methods("GET")
+	a.r.HandleFunc("/snapshot", a.handleSnapshot).Methods("GET")
+	a.r.HandleFunc("/stats", a.handleStats).Methods("GET")
 
 	log.Printf("[API] Routes registered - listening on %s", a.address)
 
-	if err := http.ListenAndServe(a.address, a.r); err != nil {
+	if err := a.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 		log.Printf("[API] Server failed to start: %v", err)
+		return err
 	}
+
+	log.Println("[API] Server stopped")
+	return nil
+}
+
+func (a *API) Shutdown(ctx context.Context) error {
+	log.Println("[API] Shutting down API server")
+
+	if err := a.server.Shutdown(ctx); err != nil {
+		log.Printf("[API] Error shutting down server: %v", err)
+		return err
+	}
+
+	log.Println("[API] API server shut down")
+	return nil
 }
 
 func (a *API) handleAuth(w http.ResponseWriter, r *http.Request) {
@@ -134,6 +196,11 @@ func (a *API) handleSendData(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if body.Aggregated() {
+		a.sendAggregatedData(w, body)
+		return
+	}
+
 	sensor, err := a.db.GetSensorByNameAndModuleAndSection(
 		body.Sensor,
 		body.Module,
@@ -147,6 +214,8 @@ func (a *API) handleSendData(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	sensor.LabelRecords()
+
 	log.Printf("[API] Data request successful - found %d records for sensor %s",
 		len(sensor.Records), sensor.Name)
 
@@ -155,10 +224,66 @@ func (a *API) handleSendData(w http.ResponseWriter, r *http.Request) {
 
 	json.NewEncoder(w).Encode(
 		map[string]any{
-			"module":  body.Module,
-			"name":    sensor.Name,
-			"records": sensor.Records,
-			"section": body.Section,
+			"module":      body.Module,
+			"name":        sensor.Name,
+			"records":     sensor.Records,
+			"section":     body.Section,
+			"config_id":   sensor.ConfigID,
+			"type":        sensor.Type,
+			"unit":        sensor.Unit,
+			"description": sensor.Description,
+			"min":         sensor.Min,
+			"max":         sensor.Max,
+			"states":      sensor.States,
+		},
+	)
+}
+
+func (a *API) sendAggregatedData(w http.ResponseWriter, body *DataRequestBody) {
+	opts, err := body.AggregateOptions(time.Now())
+	if err != nil {
+		log.Printf("[API] Data request failed - invalid aggregation: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	sensor, err := a.db.FindSensorByNameAndModuleAndSection(body.Sensor, body.Module, body.Section)
+	if err != nil {
+		log.Printf("[API] Data request failed - sensor not found: %v", err)
+		http.Error(w, "sensor not found", http.StatusNotFound)
+		return
+	}
+
+	buckets, err := a.db.GetRecordBuckets(sensor.ID, opts.From, opts.To, opts.Bucket, opts.Aggregates)
+	if err != nil {
+		log.Printf("[API] Data request failed - aggregation error: %v", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("[API] Data request successful - found %d buckets of %s for sensor %s",
+		len(buckets), opts.Bucket, sensor.Name)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	json.NewEncoder(w).Encode(
+		map[string]any{
+			"module":      body.Module,
+			"name":        sensor.Name,
+			"section":     body.Section,
+			"config_id":   sensor.ConfigID,
+			"type":        sensor.Type,
+			"unit":        sensor.Unit,
+			"description": sensor.Description,
+			"min":         sensor.Min,
+			"max":         sensor.Max,
+			"states":      sensor.States,
+			"from":        opts.From,
+			"to":          opts.To,
+			"bucket":      opts.Bucket.String(),
+			"aggregates":  opts.Aggregates,
+			"buckets":     buckets,
 		},
 	)
 }
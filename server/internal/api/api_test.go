@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/ApexCorse/ephoros/server/internal/db"
 	"github.com/gorilla/mux"
@@ -89,6 +90,9 @@ func TestHandleSendData_Success(t *testing.T) {
 	sensor := &db.Sensor{
 		Name:     "Test",
 		ModuleID: module.ID,
+		ConfigID: 7,
+		Type:     db.TypeInt16,
+		Unit:     "°C",
 	}
 	gormDb.Create(sensor)
 
@@ -138,6 +142,9 @@ func TestHandleSendData_Success(t *testing.T) {
 	assert.Equal(t, "Test", response["section"])
 	assert.Equal(t, "Test", response["module"])
 	assert.Equal(t, "Test", response["name"])
+	assert.Equal(t, float64(7), response["config_id"])
+	assert.Equal(t, float64(db.TypeInt16), response["type"])
+	assert.Equal(t, "°C", response["unit"])
 	assert.Len(t, response["records"], 3)
 	assert.IsType(t, []any{}, response["records"])
 	assert.IsType(t, map[string]any{}, response["records"].([]any)[0])
@@ -188,3 +195,169 @@ func TestHandleSendData_AuthenticationFailure(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, "invalid credentials\n", string(body))
 }
+
+func TestHandleSendData_Aggregated(t *testing.T) {
+	gormDb, cleanUp, err := db.TestDB()
+	if err != nil {
+		t.Fatal("cannot setup db")
+	}
+	defer cleanUp()
+
+	api := NewAPI(&APIConfig{
+		DB:     db.NewDB(gormDb),
+		Router: mux.NewRouter(),
+	})
+
+	gormDb.Create(&db.User{Username: "Apex", Token: "Corse"})
+
+	section := &db.Section{Name: "Test"}
+	gormDb.Create(section)
+	module := &db.Module{Name: "Test", SectionID: section.ID}
+	gormDb.Create(module)
+	sensor := &db.Sensor{Name: "Test", ModuleID: module.ID, Unit: "A"}
+	gormDb.Create(sensor)
+
+	start := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	records := make([]*db.Record, 0, 120)
+	for i := range 120 {
+		records = append(records, &db.Record{
+			SensorID:  sensor.ID,
+			Value:     float64(i),
+			CreatedAt: start.Add(time.Duration(i) * time.Second),
+		})
+	}
+	gormDb.Create(records)
+
+	server := httptest.NewServer(http.HandlerFunc(api.handleSendData))
+	defer server.Close()
+
+	post := func(requestBody *DataRequestBody) *http.Response {
+		b, err := json.Marshal(requestBody)
+		assert.Nil(t, err)
+
+		request, err := http.NewRequest(http.MethodPost, server.URL, bytes.NewBuffer(b))
+		assert.Nil(t, err)
+		request.Header.Set("Authorization", "Bearer Corse")
+
+		resp, err := http.DefaultClient.Do(request)
+		assert.Nil(t, err)
+		return resp
+	}
+
+	resp := post(&DataRequestBody{
+		Section:   "Test",
+		Module:    "Test",
+		Sensor:    "Test",
+		From:      start,
+		To:        start.Add(2 * time.Minute),
+		MaxPoints: 4,
+	})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	response := make(map[string]any)
+	err = json.NewDecoder(resp.Body).Decode(&response)
+	assert.Nil(t, err)
+
+	assert.Equal(t, "Test", response["name"])
+	assert.Equal(t, "A", response["unit"])
+	assert.Equal(t, "30s", response["bucket"])
+	assert.Equal(t, []any{"min", "max", "avg"}, response["aggregates"])
+	assert.Nil(t, response["records"])
+	assert.Len(t, response["buckets"], 4)
+
+	first := response["buckets"].([]any)[0].(map[string]any)
+	assert.Equal(t, float64(0), first["min"])
+	assert.Equal(t, float64(29), first["max"])
+	assert.Equal(t, 14.5, first["avg"])
+	assert.NotContains(t, first, "count")
+
+	resp = post(&DataRequestBody{Section: "Test", Module: "Test", Sensor: "Test", Bucket: "never"})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp = post(&DataRequestBody{Section: "Test", Module: "Test", Sensor: "Missing", Bucket: "1s"})
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestHandleAlerts(t *testing.T) {
+	gormDb, cleanUp, err := db.TestDB()
+	if err != nil {
+		t.Fatal("cannot setup db")
+	}
+	defer cleanUp()
+
+	api := NewAPI(&APIConfig{
+		DB:     db.NewDB(gormDb),
+		Router: mux.NewRouter(),
+	})
+
+	user := &db.User{
+		Username: "Apex",
+		Token:    "Corse",
+	}
+	gormDb.Create(user)
+
+	resolvedAt := time.Now()
+	alerts := []*db.Alert{
+		{Rule: "cell-overtemp", Kind: "max", Section: "Battery", Module: "Module 1", Sensor: "NTC-1", FiredAt: resolvedAt.Add(-time.Minute), ResolvedAt: &resolvedAt},
+		{Rule: "pack-undervoltage", Kind: "min", Section: "Battery", Module: "Pack", Sensor: "Voltage", FiredAt: resolvedAt},
+	}
+	gormDb.Create(alerts)
+
+	server := httptest.NewServer(http.HandlerFunc(api.handleAlerts))
+	defer server.Close()
+
+	request, err := http.NewRequest(http.MethodGet, server.URL+"?active=true", nil)
+	assert.Nil(t, err)
+	request.Header.Set("Authorization", "Bearer Corse")
+
+	resp, err := http.DefaultClient.Do(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	response := make(map[string][]db.Alert)
+	assert.Nil(t, json.NewDecoder(resp.Body).Decode(&response))
+	assert.Len(t, response["alerts"], 1)
+	assert.Equal(t, "pack-undervoltage", response["alerts"][0].Rule)
+
+	request.URL.RawQuery = ""
+	resp, err = http.DefaultClient.Do(request)
+	assert.Nil(t, err)
+
+	response = make(map[string][]db.Alert)
+	assert.Nil(t, json.NewDecoder(resp.Body).Decode(&response))
+	assert.Len(t, response["alerts"], 2)
+
+	request.Header.Set("Authorization", "Bearer Cors")
+	resp, err = http.DefaultClient.Do(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestParseAlertsQuery(t *testing.T) {
+	tests := []struct {
+		query      string
+		activeOnly bool
+		limit      int
+		valid      bool
+	}{
+		{query: "", limit: defaultAlertsLimit, valid: true},
+		{query: "active=true&limit=5", activeOnly: true, limit: 5, valid: true},
+		{query: "limit=100000", limit: maxAlertsLimit, valid: true},
+		{query: "active=maybe"},
+		{query: "limit=0"},
+		{query: "limit=ten"},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/alerts?"+tt.query, nil)
+		activeOnly, limit, err := parseAlertsQuery(r)
+		if !tt.valid {
+			assert.Error(t, err, tt.query)
+			continue
+		}
+
+		assert.Nil(t, err, tt.query)
+		assert.Equal(t, tt.activeOnly, activeOnly, tt.query)
+		assert.Equal(t, tt.limit, limit, tt.query)
+	}
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/ApexCorse/ephoros/server/internal/latest"
+)
+
+type SnapshotEntry struct {
+	latest.Value
+	AgeMs int64 `json:"age_ms"`
+}
+
+func (a *API) handleSnapshot(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[API] Snapshot request received from %s", r.RemoteAddr)
+
+	token, err := a.getTokenFromRequest(r)
+	if err != nil {
+		log.Printf("[API] Snapshot request failed - token error: %v", err)
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if err := a.validateUser(token); err != nil {
+		log.Printf("[API] Snapshot request failed - validation error: %v", err)
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if a.latest == nil {
+		log.Println("[API] Snapshot request failed - no latest value store configured")
+		http.Error(w, "snapshot not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	query := r.URL.Query()
+	section, module := query.Get("section"), query.Get("module")
+
+	now := time.Now()
+	entries := buildSnapshot(a.latest.Values(), section, module, now)
+
+	log.Printf("[API] Snapshot request successful - %d sensors (section: %q, module: %q)", len(entries), section, module)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	json.NewEncoder(w).Encode(
+		map[string]any{
+			"taken_at": now,
+			"sensors":  entries,
+		},
+	)
+}
+
+func buildSnapshot(values []latest.Value, section, module string, now time.Time) []SnapshotEntry {
+	entries := make([]SnapshotEntry, 0, len(values))
+
+	for _, value := range values {
+		if section != "" && value.Section != section {
+			continue
+		}
+
+		if module != "" && value.Module != module {
+			continue
+		}
+
+		entries = append(entries, SnapshotEntry{
+			Value: value,
+			AgeMs: max(now.Sub(value.Timestamp).Milliseconds(), 0),
+		})
+	}
+
+	return entries
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ApexCorse/ephoros/server/internal/bus"
+	"github.com/ApexCorse/ephoros/server/internal/db"
+	"github.com/ApexCorse/ephoros/server/internal/latest"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildSnapshot(t *testing.T) {
+	now := time.Now()
+	values := []latest.Value{
+		{Section: "Battery", Module: "Module 1", Sensor: "NTC-1", Timestamp: now.Add(-1500 * time.Millisecond)},
+		{Section: "Battery", Module: "Pack", Sensor: "Current", Timestamp: now.Add(time.Second)},
+		{Section: "Cooling", Module: "Pump", Sensor: "Speed", Timestamp: now},
+	}
+
+	entries := buildSnapshot(values, "", "", now)
+	assert.Len(t, entries, 3)
+	assert.Equal(t, int64(1500), entries[0].AgeMs)
+	assert.Equal(t, int64(0), entries[1].AgeMs, "samples timestamped in the future have no age")
+
+	entries = buildSnapshot(values, "Battery", "", now)
+	assert.Len(t, entries, 2)
+
+	entries = buildSnapshot(values, "Battery", "Pack", now)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "Current", entries[0].Sensor)
+
+	entries = buildSnapshot(values, "", "Pump", now)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "Speed", entries[0].Sensor)
+}
+
+func TestHandleSnapshot(t *testing.T) {
+	gormDb, cleanUp, err := db.TestDB()
+	if err != nil {
+		t.Fatal("cannot setup db")
+	}
+	defer cleanUp()
+
+	store := latest.NewStore(&latest.StoreConfig{})
+	store.Handle(bus.Sample{Section: "Battery", Module: "Pack", Sensor: "Current", Record: db.Record{SensorID: 1, Value: 12, CreatedAt: time.Now()}})
+	store.Handle(bus.Sample{Section: "Cooling", Module: "Pump", Sensor: "Speed", Record: db.Record{SensorID: 2, Value: 1200, CreatedAt: time.Now()}})
+
+	api := NewAPI(&APIConfig{
+		DB:     db.NewDB(gormDb),
+		Router: mux.NewRouter(),
+		Latest: store,
+	})
+
+	user := &db.User{
+		Username: "Apex",
+		Token:    "Corse",
+	}
+	gormDb.Create(user)
+
+	server := httptest.NewServer(http.HandlerFunc(api.handleSnapshot))
+	defer server.Close()
+
+	request, err := http.NewRequest(http.MethodGet, server.URL+"?section=Battery", nil)
+	assert.Nil(t, err)
+	request.Header.Set("Authorization", "Bearer Corse")
+
+	resp, err := http.DefaultClient.Do(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+
+	response := struct {
+		TakenAt time.Time       `json:"taken_at"`
+		Sensors []SnapshotEntry `json:"sensors"`
+	}{}
+	assert.Nil(t, json.NewDecoder(resp.Body).Decode(&response))
+	assert.Len(t, response.Sensors, 1)
+	assert.Equal(t, "Current", response.Sensors[0].Sensor)
+	assert.Equal(t, float64(12), response.Sensors[0].Value.Value)
+	assert.GreaterOrEqual(t, response.Sensors[0].AgeMs, int64(0))
+}
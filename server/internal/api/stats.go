@@ -0,0 +1,47 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+)
+
+func (a *API) handleStats(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[API] Stats request received from %s", r.RemoteAddr)
+
+	token, err := a.getTokenFromRequest(r)
+	if err != nil {
+		log.Printf("[API] Stats request failed - token error: %v", err)
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if err := a.validateUser(token); err != nil {
+		log.Printf("[API] Stats request failed - validation error: %v", err)
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	json.NewEncoder(w).Encode(a.buildStats())
+}
+
+func (a *API) buildStats() map[string]any {
+	stats := make(map[string]any)
+
+	if a.writer != nil {
+		stats["writer"] = a.writer.Stats()
+	}
+
+	if a.bus != nil {
+		stats["bus"] = a.bus.Stats()
+	}
+
+	if a.hub != nil {
+		stats["stream"] = a.hub.Stats()
+	}
+
+	return stats
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ApexCorse/ephoros/server/internal/bus"
+	"github.com/ApexCorse/ephoros/server/internal/db"
+	"github.com/ApexCorse/ephoros/server/internal/stream"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildStats(t *testing.T) {
+	eventBus := bus.New()
+	defer eventBus.Close()
+
+	_, err := eventBus.Subscribe(&bus.SubscriberConfig{Name: "db", QueueSize: 8}, func(bus.Sample) {})
+	assert.Nil(t, err)
+
+	writer := db.NewRecordWriter(nil, &db.RecordWriterConfig{BatchSize: 4, QueueSize: 16})
+	defer writer.Close()
+
+	hub := stream.NewHub(&stream.HubConfig{})
+	defer hub.Close()
+
+	api := NewAPI(&APIConfig{Writer: writer, Bus: eventBus, Hub: hub})
+
+	stats := api.buildStats()
+	assert.Equal(t, 16, stats["writer"].(db.RecordWriterStats).QueueCapacity)
+	assert.Len(t, stats["bus"], 1)
+	assert.Equal(t, "db", stats["bus"].([]bus.SubscriberStats)[0].Name)
+	assert.Contains(t, stats, "stream")
+
+	assert.Empty(t, NewAPI(&APIConfig{}).buildStats())
+}
+
+func TestHandleStats(t *testing.T) {
+	gormDb, cleanUp, err := db.TestDB()
+	if err != nil {
+		t.Fatal("cannot setup db")
+	}
+	defer cleanUp()
+
+	writer := db.NewRecordWriter(db.NewDB(gormDb), &db.RecordWriterConfig{BatchSize: 4, QueueSize: 16})
+	defer writer.Close()
+
+	api := NewAPI(&APIConfig{
+		DB:     db.NewDB(gormDb),
+		Router: mux.NewRouter(),
+		Writer: writer,
+	})
+
+	gormDb.Create(&db.User{Username: "Apex", Token: "Corse"})
+
+	server := httptest.NewServer(http.HandlerFunc(api.handleStats))
+	defer server.Close()
+
+	request, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	assert.Nil(t, err)
+
+	resp, err := http.DefaultClient.Do(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	request.Header.Set("Authorization", "Bearer Corse")
+	resp, err = http.DefaultClient.Do(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	response := struct {
+		Writer db.RecordWriterStats `json:"writer"`
+	}{}
+	err = json.NewDecoder(resp.Body).Decode(&response)
+	assert.Nil(t, err)
+	assert.Equal(t, 16, response.Writer.QueueCapacity)
+}
@@ -0,0 +1,138 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ApexCorse/ephoros/server/internal/stream"
+)
+
+var streamHeartbeatInterval = 15 * time.Second
+
+func (a *API) handleStream(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[API] Stream request received from %s", r.RemoteAddr)
+
+	token, err := a.getStreamToken(r)
+	if err != nil {
+		log.Printf("[API] Stream request failed - token error: %v", err)
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if err := a.validateUser(token); err != nil {
+		log.Printf("[API] Stream request failed - validation error: %v", err)
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if a.hub == nil {
+		log.Println("[API] Stream request failed - no stream hub configured")
+		http.Error(w, "streaming not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	selectors, err := parseSelectors(r.URL.Query()["selector"])
+	if err != nil {
+		log.Printf("[API] Stream request failed - invalid selectors: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		log.Println("[API] Stream request failed - response writer does not support flushing")
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	sub, err := a.hub.Subscribe(selectors)
+	if err != nil {
+		log.Printf("[API] Stream request failed - subscribe error: %v", err)
+		http.Error(w, "streaming not available", http.StatusServiceUnavailable)
+		return
+	}
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	log.Printf("[API] Stream opened for %s - Selectors: %v", r.RemoteAddr, selectors)
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			log.Printf("[API] Stream closed by client %s", r.RemoteAddr)
+			return
+		case <-sub.Done():
+			log.Printf("[API] Stream for %s terminated: %v", r.RemoteAddr, sub.Err())
+			fmt.Fprintf(w, "event: error\ndata: %s\n\n", sub.Err())
+			flusher.Flush()
+			return
+		case event := <-sub.Events():
+			data, err := json.Marshal(event)
+			if err != nil {
+				log.Printf("[API] Error encoding stream event: %v", err)
+				continue
+			}
+
+			if _, err := fmt.Fprintf(w, "event: record\ndata: %s\n\n", data); err != nil {
+				log.Printf("[API] Error writing to stream for %s: %v", r.RemoteAddr, err)
+				return
+			}
+			flusher.Flush()
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				log.Printf("[API] Error writing to stream for %s: %v", r.RemoteAddr, err)
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func (a *API) getStreamToken(r *http.Request) (string, error) {
+	if r.Header.Get("Authorization") != "" {
+		return a.getTokenFromRequest(r)
+	}
+
+	if token := r.URL.Query().Get("token"); token != "" {
+		log.Println("[API] Token extracted from query string")
+		return token, nil
+	}
+
+	log.Println("[API] No authorization header or token parameter provided")
+	return "", errors.New("no token provided")
+}
+
+func parseSelectors(values []string) ([]stream.Selector, error) {
+	selectors := make([]stream.Selector, 0, len(values))
+
+	for _, value := range values {
+		for _, s := range strings.Split(value, ",") {
+			selector, err := stream.ParseSelector(strings.TrimSpace(s))
+			if err != nil {
+				return nil, err
+			}
+
+			selectors = append(selectors, selector)
+		}
+	}
+
+	if len(selectors) == 0 {
+		return nil, errors.New("at least one selector is required")
+	}
+
+	return selectors, nil
+}
@@ -0,0 +1,188 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ApexCorse/ephoros/server/internal/db"
+	"github.com/ApexCorse/ephoros/server/internal/stream"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func readStreamEvent(t *testing.T, reader *bufio.Reader) (string, string) {
+	var event, data string
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("cannot read stream: %v", err)
+		}
+
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case line == "":
+			if event != "" {
+				return event, data
+			}
+		case strings.HasPrefix(line, "event: "):
+			event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func waitForSubscribers(t *testing.T, hub *stream.Hub, n int) {
+	for range 100 {
+		if hub.Stats().Subscribers == n {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatalf("expected %d stream subscribers, got %d", n, hub.Stats().Subscribers)
+}
+
+func TestHandleStream_Success(t *testing.T) {
+	gormDb, cleanUp, err := db.TestDB()
+	if err != nil {
+		t.Fatal("cannot setup db")
+	}
+	defer cleanUp()
+
+	hub := stream.NewHub(&stream.HubConfig{BufferSize: 8})
+	api := NewAPI(&APIConfig{
+		DB:     db.NewDB(gormDb),
+		Router: mux.NewRouter(),
+		Hub:    hub,
+	})
+
+	user := &db.User{
+		Username: "Apex",
+		Token:    "Corse",
+	}
+	gormDb.Create(user)
+
+	server := httptest.NewServer(http.HandlerFunc(api.handleStream))
+	defer server.Close()
+
+	query := url.Values{}
+	query.Add("selector", "Battery/+/Current")
+	query.Add("token", "Corse")
+
+	resp, err := http.Get(server.URL + "?" + query.Encode())
+	assert.Nil(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	waitForSubscribers(t, hub, 1)
+
+	hub.Publish(stream.Event{Section: "Cooling", Module: "Pump", Sensor: "Current", Record: db.Record{Value: 1}})
+	hub.Publish(stream.Event{Section: "Battery", Module: "Pack", Sensor: "Current", Record: db.Record{Value: 2, Type: db.TypeInt16}})
+
+	event, data := readStreamEvent(t, bufio.NewReader(resp.Body))
+	assert.Equal(t, "record", event)
+
+	received := make(map[string]any)
+	assert.Nil(t, json.Unmarshal([]byte(data), &received))
+	assert.Equal(t, "Battery", received["section"])
+	assert.Equal(t, "Pack", received["module"])
+	assert.Equal(t, "Current", received["sensor"])
+	assert.Equal(t, float64(2), received["record"].(map[string]any)["value"])
+
+	hub.Close()
+
+	event, data = readStreamEvent(t, bufio.NewReader(resp.Body))
+	assert.Equal(t, "error", event)
+	assert.Equal(t, stream.ErrHubClosed.Error(), data)
+}
+
+func TestHandleStream_Failure(t *testing.T) {
+	gormDb, cleanUp, err := db.TestDB()
+	if err != nil {
+		t.Fatal("cannot setup db")
+	}
+	defer cleanUp()
+
+	api := NewAPI(&APIConfig{
+		DB:     db.NewDB(gormDb),
+		Router: mux.NewRouter(),
+		Hub:    stream.NewHub(&stream.HubConfig{}),
+	})
+
+	user := &db.User{
+		Username: "Apex",
+		Token:    "Corse",
+	}
+	gormDb.Create(user)
+
+	server := httptest.NewServer(http.HandlerFunc(api.handleStream))
+	defer server.Close()
+
+	tests := []struct {
+		query  string
+		status int
+		body   string
+	}{
+		{query: "selector=Battery/%23", status: http.StatusUnauthorized, body: "no token provided\n"},
+		{query: "selector=Battery/%23&token=Cors", status: http.StatusUnauthorized, body: "invalid credentials\n"},
+		{query: "token=Corse", status: http.StatusBadRequest, body: "at least one selector is required\n"},
+		{query: "selector=Battery&token=Corse", status: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		resp, err := http.Get(server.URL + "?" + tt.query)
+		assert.Nil(t, err)
+		assert.Equal(t, tt.status, resp.StatusCode, tt.query)
+
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Nil(t, err)
+		if tt.body != "" {
+			assert.Equal(t, tt.body, string(body), tt.query)
+		}
+	}
+}
+
+func TestParseSelectors(t *testing.T) {
+	selectors, err := parseSelectors([]string{"Battery/#,Cooling/Pump/Speed", " +/+/Current "})
+	assert.Nil(t, err)
+	assert.Len(t, selectors, 3)
+	assert.Equal(t, "+/+/Current", selectors[2].String())
+
+	_, err = parseSelectors(nil)
+	assert.Error(t, err)
+
+	_, err = parseSelectors([]string{"Battery/#,"})
+	assert.Error(t, err)
+}
+
+func TestGetStreamToken(t *testing.T) {
+	api := NewAPI(&APIConfig{Router: mux.NewRouter()})
+
+	r := httptest.NewRequest(http.MethodGet, "/stream?token=Query", nil)
+	token, err := api.getStreamToken(r)
+	assert.Nil(t, err)
+	assert.Equal(t, "Query", token)
+
+	r.Header.Set("Authorization", "Bearer Header")
+	token, err = api.getStreamToken(r)
+	assert.Nil(t, err)
+	assert.Equal(t, "Header", token)
+
+	r.Header.Set("Authorization", "Header")
+	_, err = api.getStreamToken(r)
+	assert.Error(t, err)
+
+	_, err = api.getStreamToken(httptest.NewRequest(http.MethodGet, "/stream", nil))
+	assert.Error(t, err)
+}
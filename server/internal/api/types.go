@@ -1,9 +1,30 @@
 package api
 
 import (
+	"errors"
+	"fmt"
+	"slices"
 	"time"
+
+	"github.com/ApexCorse/ephoros/server/internal/db"
+)
+
+const (
+	defaultDataWindow = 30 * time.Minute
+	maxBuckets        = 100000
 )
 
+var defaultAggregates = []db.Aggregate{db.AggregateMin, db.AggregateMax, db.AggregateAvg}
+
+var bucketSteps = []time.Duration{
+	time.Millisecond, 2 * time.Millisecond, 5 * time.Millisecond,
+	10 * time.Millisecond, 20 * time.Millisecond, 50 * time.Millisecond,
+	100 * time.Millisecond, 200 * time.Millisecond, 500 * time.Millisecond,
+	time.Second, 2 * time.Second, 5 * time.Second, 10 * time.Second, 15 * time.Second, 30 * time.Second,
+	time.Minute, 2 * time.Minute, 5 * time.Minute, 10 * time.Minute, 15 * time.Minute, 30 * time.Minute,
+	time.Hour, 2 * time.Hour, 3 * time.Hour, 6 * time.Hour, 12 * time.Hour, 24 * time.Hour,
+}
+
 type DataRequestBody struct {
 	Section string `json:"section"`
 	Module  string `json:"module"`
@@ -11,8 +32,94 @@ type DataRequestBody struct {
 
 	From time.Time `json:"from"`
 	To   time.Time `json:"to"`
+
+	Bucket     string   `json:"bucket,omitempty"`
+	Aggregates []string `json:"aggregates,omitempty"`
+	MaxPoints  int      `json:"max_points,omitempty"`
 }
 
 func (b *DataRequestBody) Validate() bool {
 	return b.Section != "" && b.Module != "" && b.Sensor != ""
 }
+
+func (b *DataRequestBody) Aggregated() bool {
+	return b.Bucket != "" || b.MaxPoints > 0 || len(b.Aggregates) > 0
+}
+
+type AggregateOptions struct {
+	From       time.Time
+	To         time.Time
+	Bucket     time.Duration
+	Aggregates []db.Aggregate
+}
+
+func (b *DataRequestBody) AggregateOptions(now time.Time) (*AggregateOptions, error) {
+	opts := &AggregateOptions{From: b.From, To: b.To}
+
+	if opts.To.IsZero() {
+		opts.To = now
+	}
+
+	if opts.From.IsZero() {
+		opts.From = opts.To.Add(-defaultDataWindow)
+	}
+
+	span := opts.To.Sub(opts.From)
+	if span <= 0 {
+		return nil, errors.New("from must be before to")
+	}
+
+	if b.MaxPoints < 0 {
+		return nil, errors.New("max_points must be positive")
+	}
+
+	if b.Bucket != "" {
+		bucket, err := time.ParseDuration(b.Bucket)
+		if err != nil || bucket <= 0 {
+			return nil, fmt.Errorf("invalid bucket %q", b.Bucket)
+		}
+		opts.Bucket = bucket
+	}
+
+	if b.MaxPoints > 0 {
+		opts.Bucket = max(opts.Bucket, bucketForPoints(span, b.MaxPoints))
+	}
+
+	if opts.Bucket == 0 {
+		return nil, errors.New("bucket or max_points is required for aggregated data")
+	}
+
+	if span/opts.Bucket > maxBuckets {
+		return nil, fmt.Errorf("bucket %s is too small for the requested range, at most %d buckets are allowed", opts.Bucket, maxBuckets)
+	}
+
+	opts.Aggregates = defaultAggregates
+	if len(b.Aggregates) > 0 {
+		opts.Aggregates = make([]db.Aggregate, 0, len(b.Aggregates))
+		for _, name := range b.Aggregates {
+			aggregate, err := db.ParseAggregate(name)
+			if err != nil {
+				return nil, err
+			}
+
+			if !slices.Contains(opts.Aggregates, aggregate) {
+				opts.Aggregates = append(opts.Aggregates, aggregate)
+			}
+		}
+	}
+
+	return opts, nil
+}
+
+func bucketForPoints(span time.Duration, points int) time.Duration {
+	target := (span + time.Duration(points) - 1) / time.Duration(points)
+
+	for _, step := range bucketSteps {
+		if step >= target {
+			return step
+		}
+	}
+
+	day := bucketSteps[len(bucketSteps)-1]
+	return (target + day - 1) / day * day
+}
@@ -0,0 +1,58 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ApexCorse/ephoros/server/internal/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBucketForPoints(t *testing.T) {
+	assert.Equal(t, time.Millisecond, bucketForPoints(time.Second, 1000))
+	assert.Equal(t, 2*time.Second, bucketForPoints(30*time.Minute, 1000))
+	assert.Equal(t, 5*time.Second, bucketForPoints(time.Hour, 800))
+	assert.Equal(t, time.Hour, bucketForPoints(7*24*time.Hour, 200))
+	assert.Equal(t, 48*time.Hour, bucketForPoints(365*24*time.Hour, 200))
+}
+
+func TestAggregateOptions(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	body := &DataRequestBody{MaxPoints: 900}
+	opts, err := body.AggregateOptions(now)
+	assert.Nil(t, err)
+	assert.Equal(t, now, opts.To)
+	assert.Equal(t, now.Add(-30*time.Minute), opts.From)
+	assert.Equal(t, 2*time.Second, opts.Bucket)
+	assert.Equal(t, []db.Aggregate{db.AggregateMin, db.AggregateMax, db.AggregateAvg}, opts.Aggregates)
+
+	body = &DataRequestBody{
+		From:       now.Add(-time.Hour),
+		To:         now,
+		Bucket:     "1m",
+		Aggregates: []string{"LAST", "count", "last", "count"},
+	}
+	opts, err = body.AggregateOptions(now)
+	assert.Nil(t, err)
+	assert.Equal(t, time.Minute, opts.Bucket)
+	assert.Equal(t, []db.Aggregate{db.AggregateLast, db.AggregateCount}, opts.Aggregates)
+
+	body = &DataRequestBody{Bucket: "1s", MaxPoints: 100}
+	opts, err = body.AggregateOptions(now)
+	assert.Nil(t, err)
+	assert.Equal(t, 30*time.Second, opts.Bucket)
+
+	for _, body := range []*DataRequestBody{
+		{Bucket: "soon"},
+		{Bucket: "-1s"},
+		{MaxPoints: -1},
+		{Aggregates: []string{"avg"}},
+		{Bucket: "1s", Aggregates: []string{"median"}},
+		{Bucket: "1ms", From: now.Add(-24 * time.Hour)},
+		{Bucket: "1s", From: now, To: now.Add(-time.Minute)},
+	} {
+		_, err := body.AggregateOptions(now)
+		assert.NotNil(t, err)
+	}
+}
@@ -0,0 +1,267 @@
+package bus
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ApexCorse/ephoros/server/internal/db"
+)
+
+var (
+	ErrBusClosed        = errors.New("bus closed")
+	ErrDuplicateName    = errors.New("subscriber name already in use")
+	ErrSubscriberClosed = errors.New("subscriber closed")
+)
+
+type Policy string
+
+const (
+	PolicyDrop  Policy = "drop"
+	PolicyBlock Policy = "block"
+)
+
+type Sample struct {
+	Section string
+	Module  string
+	Sensor  string
+	Record  db.Record
+}
+
+func (s Sample) Path() string {
+	return s.Section + "/" + s.Module + "/" + s.Sensor
+}
+
+type Handler func(sample Sample)
+
+type SubscriberConfig struct {
+	Name         string
+	QueueSize    int
+	Policy       Policy
+	BlockTimeout time.Duration
+}
+
+type SubscriberStats struct {
+	Name          string `json:"name"`
+	Policy        Policy `json:"policy"`
+	Delivered     uint64 `json:"delivered"`
+	Dropped       uint64 `json:"dropped"`
+	Blocked       uint64 `json:"blocked"`
+	QueueLength   int    `json:"queue_length"`
+	QueueCapacity int    `json:"queue_capacity"`
+}
+
+type Bus struct {
+	mu          sync.RWMutex
+	subscribers []*Subscriber
+	closed      bool
+
+	published atomic.Uint64
+}
+
+type Subscriber struct {
+	bus          *Bus
+	name         string
+	policy       Policy
+	blockTimeout time.Duration
+	handler      Handler
+	queue        chan Sample
+	done         chan struct{}
+	closed       bool
+
+	delivered atomic.Uint64
+	dropped   atomic.Uint64
+	blocked   atomic.Uint64
+}
+
+func New() *Bus {
+	log.Println("[BUS] Starting event bus")
+	return &Bus{}
+}
+
+func (b *Bus) Subscribe(cfg *SubscriberConfig, handler Handler) (*Subscriber, error) {
+	if handler == nil {
+		return nil, fmt.Errorf("subscriber %q has no handler", cfg.Name)
+	}
+
+	queueSize := cfg.QueueSize
+	if queueSize <= 0 {
+		log.Printf("[BUS] Queue size for subscriber %s not set, using 1024", cfg.Name)
+		queueSize = 1024
+	}
+
+	policy := cfg.Policy
+	switch policy {
+	case PolicyDrop, PolicyBlock:
+	case "":
+		policy = PolicyDrop
+	default:
+		return nil, fmt.Errorf("subscriber %q has unknown policy %q", cfg.Name, policy)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil, ErrBusClosed
+	}
+
+	for _, sub := range b.subscribers {
+		if sub.name == cfg.Name {
+			return nil, fmt.Errorf("%w: %s", ErrDuplicateName, cfg.Name)
+		}
+	}
+
+	sub := &Subscriber{
+		bus:          b,
+		name:         cfg.Name,
+		policy:       policy,
+		blockTimeout: cfg.BlockTimeout,
+		handler:      handler,
+		queue:        make(chan Sample, queueSize),
+		done:         make(chan struct{}),
+	}
+	b.subscribers = append(b.subscribers, sub)
+
+	log.Printf("[BUS] Subscriber %s added - QueueSize: %d, Policy: %s", sub.name, queueSize, policy)
+
+	go sub.run()
+
+	return sub, nil
+}
+
+func (b *Bus) Publish(sample Sample) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if b.closed {
+		return ErrBusClosed
+	}
+
+	b.published.Add(1)
+
+	for _, sub := range b.subscribers {
+		sub.enqueue(sample)
+	}
+
+	return nil
+}
+
+func (b *Bus) Close() {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return
+	}
+	b.closed = true
+
+	subscribers := b.subscribers
+	b.subscribers = nil
+	for _, sub := range subscribers {
+		sub.closed = true
+		close(sub.queue)
+	}
+	b.mu.Unlock()
+
+	log.Printf("[BUS] Closing event bus, draining %d subscribers", len(subscribers))
+
+	for _, sub := range subscribers {
+		<-sub.done
+	}
+
+	log.Printf("[BUS] Event bus closed - Published: %d", b.published.Load())
+}
+
+func (b *Bus) Stats() []SubscriberStats {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	stats := make([]SubscriberStats, 0, len(b.subscribers))
+	for _, sub := range b.subscribers {
+		stats = append(stats, sub.Stats())
+	}
+
+	return stats
+}
+
+func (s *Subscriber) Name() string {
+	return s.name
+}
+
+func (s *Subscriber) Close() error {
+	b := s.bus
+
+	b.mu.Lock()
+	if s.closed {
+		b.mu.Unlock()
+		return ErrSubscriberClosed
+	}
+	s.closed = true
+	close(s.queue)
+
+	for i, sub := range b.subscribers {
+		if sub == s {
+			b.subscribers = append(b.subscribers[:i], b.subscribers[i+1:]...)
+			break
+		}
+	}
+	b.mu.Unlock()
+
+	<-s.done
+
+	log.Printf("[BUS] Subscriber %s removed - Delivered: %d, Dropped: %d", s.name, s.delivered.Load(), s.dropped.Load())
+	return nil
+}
+
+func (s *Subscriber) Stats() SubscriberStats {
+	return SubscriberStats{
+		Name:          s.name,
+		Policy:        s.policy,
+		Delivered:     s.delivered.Load(),
+		Dropped:       s.dropped.Load(),
+		Blocked:       s.blocked.Load(),
+		QueueLength:   len(s.queue),
+		QueueCapacity: cap(s.queue),
+	}
+}
+
+func (s *Subscriber) enqueue(sample Sample) {
+	select {
+	case s.queue <- sample:
+		return
+	default:
+	}
+
+	if s.policy == PolicyBlock {
+		s.blocked.Add(1)
+
+		if s.blockTimeout <= 0 {
+			s.queue <- sample
+			return
+		}
+
+		timer := time.NewTimer(s.blockTimeout)
+		defer timer.Stop()
+
+		select {
+		case s.queue <- sample:
+			return
+		case <-timer.C:
+		}
+	}
+
+	s.dropped.Add(1)
+	log.Printf("[BUS] Queue of subscriber %s full, dropping sample for %s", s.name, sample.Path())
+}
+
+func (s *Subscriber) run() {
+	defer close(s.done)
+
+	for sample := range s.queue {
+		s.handler(sample)
+		s.delivered.Add(1)
+	}
+}
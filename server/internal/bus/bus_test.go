@@ -0,0 +1,208 @@
+package bus
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ApexCorse/ephoros/server/internal/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func newSample(value float64) Sample {
+	return Sample{
+		Section: "Battery",
+		Module:  "Pack",
+		Sensor:  "Current",
+		Record:  db.Record{SensorID: 1, Value: value},
+	}
+}
+
+type collector struct {
+	mu      sync.Mutex
+	samples []Sample
+}
+
+func (c *collector) handle(sample Sample) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.samples = append(c.samples, sample)
+}
+
+func (c *collector) values() []float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	values := make([]float64, 0, len(c.samples))
+	for _, sample := range c.samples {
+		values = append(values, sample.Record.Value)
+	}
+
+	return values
+}
+
+func TestBus_Publish(t *testing.T) {
+	b := New()
+
+	first, second := &collector{}, &collector{}
+	_, err := b.Subscribe(&SubscriberConfig{Name: "first"}, first.handle)
+	assert.NoError(t, err)
+	_, err = b.Subscribe(&SubscriberConfig{Name: "second", Policy: PolicyBlock}, second.handle)
+	assert.NoError(t, err)
+
+	for i := range 5 {
+		assert.NoError(t, b.Publish(newSample(float64(i))))
+	}
+
+	b.Close()
+
+	assert.Equal(t, []float64{0, 1, 2, 3, 4}, first.values())
+	assert.Equal(t, []float64{0, 1, 2, 3, 4}, second.values())
+	assert.Equal(t, "Battery/Pack/Current", first.samples[0].Path())
+
+	assert.ErrorIs(t, b.Publish(newSample(5)), ErrBusClosed)
+	_, err = b.Subscribe(&SubscriberConfig{Name: "late"}, first.handle)
+	assert.ErrorIs(t, err, ErrBusClosed)
+}
+
+func TestBus_SubscribeErrors(t *testing.T) {
+	b := New()
+	defer b.Close()
+
+	_, err := b.Subscribe(&SubscriberConfig{Name: "db"}, func(Sample) {})
+	assert.NoError(t, err)
+
+	_, err = b.Subscribe(&SubscriberConfig{Name: "db"}, func(Sample) {})
+	assert.ErrorIs(t, err, ErrDuplicateName)
+
+	_, err = b.Subscribe(&SubscriberConfig{Name: "alerts", Policy: "disconnect"}, func(Sample) {})
+	assert.Error(t, err)
+
+	_, err = b.Subscribe(&SubscriberConfig{Name: "empty"}, nil)
+	assert.Error(t, err)
+}
+
+func TestBus_DropPolicy(t *testing.T) {
+	b := New()
+
+	release := make(chan struct{})
+	slow := &collector{}
+	sub, err := b.Subscribe(&SubscriberConfig{Name: "slow", QueueSize: 2, Policy: PolicyDrop}, func(sample Sample) {
+		<-release
+		slow.handle(sample)
+	})
+	assert.NoError(t, err)
+
+	fast := &collector{}
+	_, err = b.Subscribe(&SubscriberConfig{Name: "fast", QueueSize: 16}, fast.handle)
+	assert.NoError(t, err)
+
+	// The first sample is taken by the handler, two fill the queue, the rest are dropped.
+	assert.NoError(t, b.Publish(newSample(0)))
+	assert.Eventually(t, func() bool { return len(sub.queue) == 0 }, time.Second, time.Millisecond)
+	for i := 1; i < 6; i++ {
+		assert.NoError(t, b.Publish(newSample(float64(i))))
+	}
+
+	stats := sub.Stats()
+	assert.Equal(t, uint64(3), stats.Dropped)
+	assert.Equal(t, 2, stats.QueueLength)
+
+	close(release)
+	b.Close()
+
+	assert.Equal(t, []float64{0, 1, 2}, slow.values())
+	assert.Equal(t, []float64{0, 1, 2, 3, 4, 5}, fast.values())
+}
+
+func TestBus_BlockPolicy(t *testing.T) {
+	b := New()
+
+	release := make(chan struct{})
+	blocking := &collector{}
+	sub, err := b.Subscribe(&SubscriberConfig{Name: "db", QueueSize: 1, Policy: PolicyBlock}, func(sample Sample) {
+		<-release
+		blocking.handle(sample)
+	})
+	assert.NoError(t, err)
+
+	assert.NoError(t, b.Publish(newSample(0)))
+	assert.Eventually(t, func() bool { return len(sub.queue) == 0 }, time.Second, time.Millisecond)
+	assert.NoError(t, b.Publish(newSample(1)))
+
+	published := make(chan struct{})
+	go func() {
+		b.Publish(newSample(2))
+		close(published)
+	}()
+
+	select {
+	case <-published:
+		t.Fatal("publish did not block on a full queue")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	<-published
+	b.Close()
+
+	assert.Equal(t, []float64{0, 1, 2}, blocking.values())
+	assert.Equal(t, uint64(0), sub.Stats().Dropped)
+	assert.Equal(t, uint64(1), sub.Stats().Blocked)
+}
+
+func TestBus_BlockTimeout(t *testing.T) {
+	b := New()
+
+	release := make(chan struct{})
+	sub, err := b.Subscribe(&SubscriberConfig{
+		Name:         "db",
+		QueueSize:    1,
+		Policy:       PolicyBlock,
+		BlockTimeout: 10 * time.Millisecond,
+	}, func(Sample) {
+		<-release
+	})
+	assert.NoError(t, err)
+
+	assert.NoError(t, b.Publish(newSample(0)))
+	assert.Eventually(t, func() bool { return len(sub.queue) == 0 }, time.Second, time.Millisecond)
+	assert.NoError(t, b.Publish(newSample(1)))
+
+	start := time.Now()
+	assert.NoError(t, b.Publish(newSample(2)))
+	assert.GreaterOrEqual(t, time.Since(start), 10*time.Millisecond)
+
+	stats := sub.Stats()
+	assert.Equal(t, uint64(1), stats.Blocked)
+	assert.Equal(t, uint64(1), stats.Dropped)
+
+	close(release)
+	b.Close()
+	assert.Equal(t, uint64(2), sub.Stats().Delivered)
+}
+
+func TestSubscriber_Close(t *testing.T) {
+	b := New()
+	defer b.Close()
+
+	removed, kept := &collector{}, &collector{}
+	sub, err := b.Subscribe(&SubscriberConfig{Name: "removed"}, removed.handle)
+	assert.NoError(t, err)
+	_, err = b.Subscribe(&SubscriberConfig{Name: "kept"}, kept.handle)
+	assert.NoError(t, err)
+
+	assert.NoError(t, b.Publish(newSample(0)))
+	assert.NoError(t, sub.Close())
+	assert.ErrorIs(t, sub.Close(), ErrSubscriberClosed)
+
+	assert.NoError(t, b.Publish(newSample(1)))
+
+	assert.Equal(t, []float64{0}, removed.values())
+	assert.Len(t, b.Stats(), 1)
+	assert.Equal(t, "kept", b.Stats()[0].Name)
+
+	_, err = b.Subscribe(&SubscriberConfig{Name: "removed"}, removed.handle)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,102 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+const ReservedSection = "ephoros"
+
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+type AlertRuleConfig struct {
+	Name    string `json:"name" yaml:"name" toml:"name"`
+	Section string `json:"section" yaml:"section" toml:"section"`
+	Module  string `json:"module" yaml:"module" toml:"module"`
+	Sensor  string `json:"sensor,omitempty" yaml:"sensor,omitempty" toml:"sensor,omitempty"`
+
+	Min        *float64 `json:"min,omitempty" yaml:"min,omitempty" toml:"min,omitempty"`
+	Max        *float64 `json:"max,omitempty" yaml:"max,omitempty" toml:"max,omitempty"`
+	MaxRate    *float64 `json:"max_rate,omitempty" yaml:"max_rate,omitempty" toml:"max_rate,omitempty"`
+	Stale      string   `json:"stale,omitempty" yaml:"stale,omitempty" toml:"stale,omitempty"`
+	Hysteresis float64  `json:"hysteresis,omitempty" yaml:"hysteresis,omitempty" toml:"hysteresis,omitzero"`
+	Severity   string   `json:"severity,omitempty" yaml:"severity,omitempty" toml:"severity,omitempty"`
+}
+
+func (c *AlertRuleConfig) Matches(section, module, sensor string) bool {
+	return c.Section == section && c.Module == module && (c.Sensor == "" || c.Sensor == sensor)
+}
+
+func (c *AlertRuleConfig) StaleTimeout() time.Duration {
+	if c.Stale == "" {
+		return 0
+	}
+
+	d, err := time.ParseDuration(c.Stale)
+	if err != nil {
+		return 0
+	}
+
+	return d
+}
+
+func (c *AlertRuleConfig) SeverityLevel() string {
+	if c.Severity == "" {
+		return SeverityWarning
+	}
+
+	return c.Severity
+}
+
+func (c *AlertRuleConfig) validate(path string) []ValidationError {
+	errs := make([]ValidationError, 0)
+
+	errs = append(errs, validateTopicLevel(path+".name", c.Name)...)
+	errs = append(errs, validateTopicLevel(path+".section", c.Section)...)
+	errs = append(errs, validateTopicLevel(path+".module", c.Module)...)
+	if c.Sensor != "" {
+		errs = append(errs, validateTopicLevel(path+".sensor", c.Sensor)...)
+	}
+
+	if c.Min == nil && c.Max == nil && c.MaxRate == nil && c.Stale == "" {
+		errs = append(errs, ValidationError{Path: path, Message: "no condition, expected at least one of min, max, max_rate or stale"})
+	}
+
+	if c.Min != nil && c.Max != nil && *c.Min > *c.Max {
+		errs = append(errs, ValidationError{
+			Path:    path + ".min",
+			Message: fmt.Sprintf("greater than max (%g > %g)", *c.Min, *c.Max),
+		})
+	}
+
+	if c.MaxRate != nil && *c.MaxRate <= 0 {
+		errs = append(errs, ValidationError{Path: path + ".max_rate", Message: fmt.Sprintf("must be positive, got %g", *c.MaxRate)})
+	}
+
+	if c.Stale != "" {
+		if d, err := time.ParseDuration(c.Stale); err != nil {
+			errs = append(errs, ValidationError{Path: path + ".stale", Message: fmt.Sprintf("invalid duration %q", c.Stale)})
+		} else if d <= 0 {
+			errs = append(errs, ValidationError{Path: path + ".stale", Message: fmt.Sprintf("must be positive, got %s", c.Stale)})
+		}
+	}
+
+	if c.Hysteresis < 0 {
+		errs = append(errs, ValidationError{Path: path + ".hysteresis", Message: fmt.Sprintf("must not be negative, got %g", c.Hysteresis)})
+	}
+
+	switch c.Severity {
+	case "", SeverityInfo, SeverityWarning, SeverityCritical:
+	default:
+		errs = append(errs, ValidationError{
+			Path:    path + ".severity",
+			Message: fmt.Sprintf("unknown severity %q, expected %q, %q or %q", c.Severity, SeverityInfo, SeverityWarning, SeverityCritical),
+		})
+	}
+
+	return errs
+}
@@ -0,0 +1,160 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"path/filepath"
+)
+
+const (
+	ClientAuthNone    = "none"
+	ClientAuthRequest = "request"
+	ClientAuthRequire = "require"
+)
+
+type BrokerConfig struct {
+	TLS       *TLSConfig       `json:"tls,omitempty" yaml:"tls,omitempty" toml:"tls,omitempty"`
+	WebSocket *WebSocketConfig `json:"websocket,omitempty" yaml:"websocket,omitempty" toml:"websocket,omitempty"`
+}
+
+type WebSocketConfig struct {
+	Address string `json:"address" yaml:"address" toml:"address"`
+}
+
+type TLSConfig struct {
+	Address    string `json:"address" yaml:"address" toml:"address"`
+	Cert       string `json:"cert" yaml:"cert" toml:"cert"`
+	Key        string `json:"key" yaml:"key" toml:"key"`
+	CA         string `json:"ca,omitempty" yaml:"ca,omitempty" toml:"ca,omitempty"`
+	ClientAuth string `json:"client_auth,omitempty" yaml:"client_auth,omitempty" toml:"client_auth,omitempty"`
+}
+
+func (c *TLSConfig) ClientAuthMode() string {
+	if c.ClientAuth != "" {
+		return c.ClientAuth
+	}
+
+	if c.CA != "" {
+		return ClientAuthRequire
+	}
+
+	return ClientAuthNone
+}
+
+func (c *BrokerConfig) validate(path string) []ValidationError {
+	errs := make([]ValidationError, 0)
+
+	if c.TLS != nil {
+		errs = append(errs, c.TLS.validate(path+".tls")...)
+	}
+
+	if c.WebSocket != nil {
+		if c.WebSocket.Address == "" {
+			errs = append(errs, ValidationError{Path: path + ".websocket.address", Message: "empty"})
+		} else if c.TLS != nil && SameListenAddress(c.TLS.Address, c.WebSocket.Address) {
+			errs = append(errs, ValidationError{
+				Path:    path + ".websocket.address",
+				Message: fmt.Sprintf("%s is already used by %s.tls", c.WebSocket.Address, path),
+			})
+		}
+	}
+
+	return errs
+}
+
+func (c *BrokerConfig) Listeners() map[string]string {
+	addresses := make(map[string]string)
+	if c == nil {
+		return addresses
+	}
+
+	if c.TLS != nil {
+		addresses["broker.tls"] = c.TLS.Address
+	}
+
+	if c.WebSocket != nil {
+		addresses["broker.websocket"] = c.WebSocket.Address
+	}
+
+	return addresses
+}
+
+func (c *BrokerConfig) CheckAddress(address string) error {
+	for path, listener := range c.Listeners() {
+		if SameListenAddress(address, listener) {
+			return fmt.Errorf("%s is already used by %s", address, path)
+		}
+	}
+
+	return nil
+}
+
+func SameListenAddress(a, b string) bool {
+	hostA, portA, errA := net.SplitHostPort(a)
+	hostB, portB, errB := net.SplitHostPort(b)
+	if errA != nil || errB != nil {
+		return a == b
+	}
+
+	if portA != portB {
+		return false
+	}
+
+	return hostA == hostB || isWildcardHost(hostA) || isWildcardHost(hostB)
+}
+
+func isWildcardHost(host string) bool {
+	return host == "" || host == "0.0.0.0" || host == "::"
+}
+
+func (c *TLSConfig) validate(path string) []ValidationError {
+	errs := make([]ValidationError, 0)
+
+	if c.Address == "" {
+		errs = append(errs, ValidationError{Path: path + ".address", Message: "empty"})
+	}
+
+	if c.Cert == "" {
+		errs = append(errs, ValidationError{Path: path + ".cert", Message: "empty"})
+	}
+
+	if c.Key == "" {
+		errs = append(errs, ValidationError{Path: path + ".key", Message: "empty"})
+	}
+
+	switch c.ClientAuth {
+	case "", ClientAuthNone:
+	case ClientAuthRequest, ClientAuthRequire:
+		if c.CA == "" {
+			errs = append(errs, ValidationError{
+				Path:    path + ".ca",
+				Message: fmt.Sprintf("required when client_auth is %q", c.ClientAuth),
+			})
+		}
+	default:
+		errs = append(errs, ValidationError{
+			Path:    path + ".client_auth",
+			Message: fmt.Sprintf("unknown mode %q, expected %q, %q or %q", c.ClientAuth, ClientAuthNone, ClientAuthRequest, ClientAuthRequire),
+		})
+	}
+
+	return errs
+}
+
+func (c *BrokerConfig) resolvePaths(dir string) {
+	if c.TLS == nil {
+		return
+	}
+
+	c.TLS.Cert = resolvePath(dir, c.TLS.Cert)
+	c.TLS.Key = resolvePath(dir, c.TLS.Key)
+	c.TLS.CA = resolvePath(dir, c.TLS.CA)
+}
+
+func resolvePath(dir, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+
+	return filepath.Join(dir, path)
+}
@@ -0,0 +1,160 @@
+package config
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTLSConfigValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   TLSConfig
+		problems []string
+	}{
+		{
+			name:   "server only",
+			config: TLSConfig{Address: ":8883", Cert: "server.pem", Key: "server.key"},
+		},
+		{
+			name:   "client certificates",
+			config: TLSConfig{Address: ":8883", Cert: "server.pem", Key: "server.key", CA: "ca.pem", ClientAuth: ClientAuthRequest},
+		},
+		{
+			name:     "missing fields",
+			config:   TLSConfig{},
+			problems: []string{"broker.tls.address: empty", "broker.tls.cert: empty", "broker.tls.key: empty"},
+		},
+		{
+			name:     "client auth without ca",
+			config:   TLSConfig{Address: ":8883", Cert: "server.pem", Key: "server.key", ClientAuth: ClientAuthRequire},
+			problems: []string{`broker.tls.ca: required when client_auth is "require"`},
+		},
+		{
+			name:     "unknown client auth",
+			config:   TLSConfig{Address: ":8883", Cert: "server.pem", Key: "server.key", ClientAuth: "optional"},
+			problems: []string{`broker.tls.client_auth: unknown mode "optional"`},
+		},
+	}
+
+	for _, tt := range tests {
+		broker := &BrokerConfig{TLS: &tt.config}
+		errs := broker.validate("broker")
+
+		assert.Len(t, errs, len(tt.problems), tt.name)
+		for i, problem := range tt.problems {
+			if i < len(errs) {
+				assert.True(t, strings.HasPrefix(errs[i].Error(), problem), "%s: %s", tt.name, errs[i].Error())
+			}
+		}
+	}
+}
+
+func TestTLSConfigClientAuthMode(t *testing.T) {
+	assert.Equal(t, ClientAuthNone, (&TLSConfig{}).ClientAuthMode())
+	assert.Equal(t, ClientAuthRequire, (&TLSConfig{CA: "ca.pem"}).ClientAuthMode())
+	assert.Equal(t, ClientAuthRequest, (&TLSConfig{CA: "ca.pem", ClientAuth: ClientAuthRequest}).ClientAuthMode())
+}
+
+func TestNewConfigFromFile_Broker(t *testing.T) {
+	dir := t.TempDir()
+	writeConfigFiles(t, dir, map[string]string{
+		"config.yaml": `
+broker:
+  tls:
+    address: ":8883"
+    cert: certs/server.pem
+    key: /etc/ephoros/server.key
+    ca: certs/ca.pem
+sensors:
+  - {name: Current, id: 1, section: Battery, module: Pack}
+`,
+	})
+
+	config, err := NewConfigFromFile(filepath.Join(dir, "config.yaml"))
+	assert.NoError(t, err)
+	assert.NotNil(t, config.Broker)
+	assert.Equal(t, filepath.Join(dir, "certs/server.pem"), config.Broker.TLS.Cert)
+	assert.Equal(t, "/etc/ephoros/server.key", config.Broker.TLS.Key)
+	assert.Equal(t, filepath.Join(dir, "certs/ca.pem"), config.Broker.TLS.CA)
+	assert.Equal(t, ClientAuthRequire, config.Broker.TLS.ClientAuthMode())
+}
+
+func TestNewConfigFromFile_BrokerErrors(t *testing.T) {
+	dir := t.TempDir()
+	writeConfigFiles(t, dir, map[string]string{
+		"config.yaml": `
+include: [extra.yaml]
+sensors:
+  - {name: Current, id: 1, section: Battery, module: Pack}
+`,
+		"extra.yaml": `
+broker:
+  tls: {address: ":8883", cert: server.pem, key: server.key}
+`,
+		"invalid.yaml": `
+broker:
+  tls: {address: ":8883", client_auth: require}
+`,
+	})
+
+	_, err := NewConfigFromFile(filepath.Join(dir, "config.yaml"))
+	assert.EqualError(t, err, "invalid configuration: extra.yaml: broker: only allowed in the main configuration file")
+
+	_, err = NewConfigFromFile(filepath.Join(dir, "invalid.yaml"))
+	var validationErrs ValidationErrors
+	assert.True(t, errors.As(err, &validationErrs))
+	assert.Len(t, validationErrs, 3)
+}
+
+func TestBrokerConfigValidate_WebSocket(t *testing.T) {
+	broker := &BrokerConfig{WebSocket: &WebSocketConfig{Address: ":8080"}}
+	assert.Empty(t, broker.validate("broker"))
+
+	broker = &BrokerConfig{WebSocket: &WebSocketConfig{}}
+	errs := broker.validate("broker")
+	assert.Len(t, errs, 1)
+	assert.Equal(t, "broker.websocket.address: empty", errs[0].Error())
+
+	broker = &BrokerConfig{
+		TLS:       &TLSConfig{Address: ":8883", Cert: "server.pem", Key: "server.key"},
+		WebSocket: &WebSocketConfig{Address: ":8883"},
+	}
+	errs = broker.validate("broker")
+	assert.Len(t, errs, 1)
+	assert.Equal(t, "broker.websocket.address: :8883 is already used by broker.tls", errs[0].Error())
+}
+
+func TestBrokerConfigCheckAddress(t *testing.T) {
+	var none *BrokerConfig
+	assert.Empty(t, none.Listeners())
+	assert.Nil(t, none.CheckAddress(":1883"))
+
+	broker := &BrokerConfig{
+		TLS:       &TLSConfig{Address: ":8883", Cert: "server.pem", Key: "server.key"},
+		WebSocket: &WebSocketConfig{Address: "127.0.0.1:8081"},
+	}
+	assert.Len(t, broker.Listeners(), 2)
+	assert.Nil(t, broker.CheckAddress(":1883"))
+	assert.Nil(t, broker.CheckAddress("10.0.0.1:8081"))
+	assert.EqualError(t, broker.CheckAddress("0.0.0.0:8883"), "0.0.0.0:8883 is already used by broker.tls")
+	assert.EqualError(t, broker.CheckAddress(":8081"), ":8081 is already used by broker.websocket")
+
+	broker.WebSocket.Address = "[::]:8883"
+	errs := broker.validate("broker")
+	assert.Len(t, errs, 1)
+	assert.Equal(t, "broker.websocket.address: [::]:8883 is already used by broker.tls", errs[0].Error())
+}
+
+func TestSameListenAddress(t *testing.T) {
+	assert.True(t, SameListenAddress(":1883", ":1883"))
+	assert.True(t, SameListenAddress(":1883", "0.0.0.0:1883"))
+	assert.True(t, SameListenAddress("127.0.0.1:1883", ":1883"))
+	assert.True(t, SameListenAddress("127.0.0.1:1883", "127.0.0.1:1883"))
+	assert.False(t, SameListenAddress("127.0.0.1:1883", "10.0.0.1:1883"))
+	assert.False(t, SameListenAddress(":1883", ":8883"))
+	assert.True(t, SameListenAddress("broker", "broker"))
+}
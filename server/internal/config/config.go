@@ -1,60 +1,108 @@
 package config
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/ApexCorse/ephoros/server/internal/db"
 )
 
 type SensorConfig struct {
-	Name    string `json:"name"`
-	ID      uint   `json:"id"`
-	Section string `json:"section"`
-	Module  string `json:"module"`
-	Type    uint   `json:"type"`
+	Name    string `json:"name" yaml:"name" toml:"name"`
+	ID      uint   `json:"id" yaml:"id" toml:"id"`
+	Section string `json:"section" yaml:"section" toml:"section"`
+	Module  string `json:"module" yaml:"module" toml:"module"`
+	Type    uint   `json:"type" yaml:"type" toml:"type"`
+
+	// Encoding names the payload layout, e.g. "int16" or "scaled_uint16".
+	// Type predates it and is ignored when decoding: sensors without an
+	// encoding keep being decoded as float32, whatever their type.
+	Encoding string `json:"encoding,omitempty" yaml:"encoding,omitempty" toml:"encoding,omitempty"`
+
+	Unit        string   `json:"unit,omitempty" yaml:"unit,omitempty" toml:"unit,omitempty"`
+	Description string   `json:"description,omitempty" yaml:"description,omitempty" toml:"description,omitempty"`
+	Min         *float64 `json:"min,omitempty" yaml:"min,omitempty" toml:"min,omitempty"`
+	Max         *float64 `json:"max,omitempty" yaml:"max,omitempty" toml:"max,omitempty"`
+	Gain        float64  `json:"gain,omitempty" yaml:"gain,omitempty" toml:"gain,omitzero"`
+	Offset      float64  `json:"offset,omitempty" yaml:"offset,omitempty" toml:"offset,omitzero"`
+	States      []string `json:"states,omitempty" yaml:"states,omitempty" toml:"states,omitempty"`
+	IDStride    []uint   `json:"id_stride,omitempty" yaml:"id_stride,omitempty" toml:"id_stride,omitempty"`
 }
 
 func (c *SensorConfig) Validate() bool {
-	log.Printf("[CONFIG] Validating sensor config - Name: %s, ID: %d, Section: %s, Module: %s, Type: %d",
-		c.Name, c.ID, c.Section, c.Module, c.Type)
+	log.Printf("[CONFIG] Validating sensor config - Name: %s, ID: %d, Section: %s, Module: %s, Encoding: %s",
+		c.Name, c.ID, c.Section, c.Module, c.Encoding)
 
-	isValid := c.Name != "" && c.Section != "" && c.Module != ""
+	errs := c.validate("sensor")
+	for _, err := range errs {
+		log.Printf("[CONFIG] Sensor config validation failed - %v", err)
+	}
 
-	if !isValid {
-		log.Printf("[CONFIG] Sensor config validation failed - Name: %s, Section: %s, Module: %s",
-			c.Name, c.Section, c.Module)
-	} else {
+	if len(errs) == 0 {
 		log.Printf("[CONFIG] Sensor config validation successful - Name: %s", c.Name)
 	}
 
-	return isValid
+	return len(errs) == 0
+}
+
+func (c *SensorConfig) ValueType() (db.ValueType, error) {
+	if c.Encoding == "" {
+		return db.TypeFloat32, nil
+	}
+
+	return db.ParseValueType(c.Encoding)
 }
 
 type MQTTUserConfig struct {
-	Username string `json:"username"`
-	Password string `json:"password"`
+	Username  string   `json:"username" yaml:"username" toml:"username"`
+	Password  string   `json:"password" yaml:"password" toml:"password"`
+	Publish   []string `json:"publish,omitempty" yaml:"publish,omitempty" toml:"publish,omitempty"`
+	Subscribe []string `json:"subscribe,omitempty" yaml:"subscribe,omitempty" toml:"subscribe,omitempty"`
+}
+
+func (c *MQTTUserConfig) HasACL() bool {
+	return len(c.Publish) > 0 || len(c.Subscribe) > 0
+}
+
+func (c MQTTUserConfig) String() string {
+	return fmt.Sprintf("{Username:%s Password:[redacted] Publish:%v Subscribe:%v}", c.Username, c.Publish, c.Subscribe)
+}
+
+func (c MQTTUserConfig) GoString() string {
+	return fmt.Sprintf("config.MQTTUserConfig{Username:%q, Password:\"[redacted]\", Publish:%#v, Subscribe:%#v}",
+		c.Username, c.Publish, c.Subscribe)
 }
 
 func (c *MQTTUserConfig) Validate() bool {
 	log.Printf("[CONFIG] Validating MQTT user config - Username: %s", c.Username)
 
-	isValid := c.Username != "" && c.Password != ""
+	errs := c.validate("mqtt")
+	for _, err := range errs {
+		log.Printf("[CONFIG] MQTT user config validation failed - %v", err)
+	}
 
-	if !isValid {
-		log.Printf("[CONFIG] MQTT user config validation failed - Username: %s, Password provided: %t",
-			c.Username, c.Password != "")
-	} else {
+	if len(errs) == 0 {
 		log.Printf("[CONFIG] MQTT user config validation successful - Username: %s", c.Username)
 	}
 
-	return isValid
+	return len(errs) == 0
 }
 
 type Config struct {
-	SensorConfigs []SensorConfig   `json:"sensors"`
-	MQTT          []MQTTUserConfig `json:"mqtt"`
+	Include       []string          `json:"include,omitempty" yaml:"include,omitempty" toml:"include,omitempty"`
+	Broker        *BrokerConfig     `json:"broker,omitempty" yaml:"broker,omitempty" toml:"broker,omitempty"`
+	SensorConfigs []SensorConfig    `json:"sensors" yaml:"sensors" toml:"sensors"`
+	MQTT          []MQTTUserConfig  `json:"mqtt" yaml:"mqtt" toml:"mqtt"`
+	Alerts        []AlertRuleConfig `json:"alerts,omitempty" yaml:"alerts,omitempty" toml:"alerts,omitempty"`
+
+	sources       []string
+	sensorOrigins []origin
+	mqttOrigins   []origin
+	alertOrigins  []origin
 }
 
 func NewConfig(configs []SensorConfig, mqtt []MQTTUserConfig) *Config {
@@ -70,37 +118,114 @@ func NewConfig(configs []SensorConfig, mqtt []MQTTUserConfig) *Config {
 func NewConfigFromReader(reader io.Reader) (*Config, error) {
 	log.Println("[CONFIG] Loading configuration from reader")
 
-	config := &Config{}
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		log.Printf("[CONFIG] Error reading configuration: %v", err)
+		return nil, err
+	}
+
+	return newConfig(content, DetectFormat(content))
+}
+
+func NewConfigFromReaderWithFormat(reader io.Reader, format Format) (*Config, error) {
+	log.Printf("[CONFIG] Loading %s configuration from reader", format)
 
-	err := json.NewDecoder(reader).Decode(config)
+	content, err := io.ReadAll(reader)
 	if err != nil {
-		log.Printf("[CONFIG] Error decoding JSON configuration: %v", err)
+		log.Printf("[CONFIG] Error reading configuration: %v", err)
 		return nil, err
 	}
 
-	log.Printf("[CONFIG] JSON decoded successfully - Sensors: %d, MQTT configs: %d",
-		len(config.SensorConfigs), len(config.MQTT))
+	return newConfig(content, format)
+}
 
-	log.Println("[CONFIG] Validating sensor configurations")
-	for i, sConfig := range config.SensorConfigs {
-		if !sConfig.Validate() {
-			log.Printf("[CONFIG] Sensor config validation failed at index %d", i+1)
-			return nil, fmt.Errorf("config nº%d not valid", i+1)
-		}
+func newConfig(content []byte, format Format) (*Config, error) {
+	config, err := DecodeConfig(content, format)
+	if err != nil {
+		return nil, err
 	}
 
-	log.Println("[CONFIG] Validating MQTT configurations")
-	for i, mConfig := range config.MQTT {
-		if !mConfig.Validate() {
-			log.Printf("[CONFIG] MQTT config validation failed at index %d", i+1)
-			return nil, fmt.Errorf("mqtt config nº%d not valid", i+1)
+	if len(config.Include) > 0 {
+		dir, err := os.Getwd()
+		if err != nil {
+			return nil, err
 		}
+
+		loader := &includeLoader{root: dir}
+		if err := loader.resolve(config, dir, ""); err != nil {
+			log.Printf("[CONFIG] Error resolving includes: %v", err)
+			return nil, err
+		}
+	}
+
+	return finishConfig(config)
+}
+
+func finishConfig(config *Config) (*Config, error) {
+	if err := config.expandTemplates(); err != nil {
+		log.Printf("[CONFIG] Error expanding sensor templates: %v", err)
+		return nil, err
+	}
+
+	if err := config.interpolate(); err != nil {
+		log.Printf("[CONFIG] Error resolving MQTT credentials: %v", err)
+		return nil, err
+	}
+
+	if err := config.Validate(); err != nil {
+		log.Printf("[CONFIG] Configuration validation failed: %v", err)
+		return nil, err
 	}
 
 	log.Println("[CONFIG] All configurations validated successfully")
 	return config, nil
 }
 
+func (c *SensorConfig) Path() string {
+	return c.Section + "/" + c.Module + "/" + c.Name
+}
+
+func NewConfigFromFile(path string) (*Config, error) {
+	log.Printf("[CONFIG] Loading configuration from file: %s", path)
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	loader := &includeLoader{root: filepath.Dir(abs)}
+
+	config, err := loader.loadFile(abs)
+	if err != nil {
+		log.Printf("[CONFIG] Error loading configuration: %v", err)
+		return nil, err
+	}
+
+	config.sources = append([]string{abs}, loader.sources...)
+
+	if config.Broker != nil {
+		config.Broker.resolvePaths(filepath.Dir(abs))
+	}
+
+	return finishConfig(config)
+}
+
+func readConfigFile(path string) ([]byte, Format, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		log.Printf("[CONFIG] Error opening configuration file: %v", err)
+		return nil, "", err
+	}
+
+	format, ok := FormatFromPath(path)
+	if !ok {
+		format = DetectFormat(content)
+		log.Printf("[CONFIG] Detected %s format from content of %s", format, path)
+	}
+
+	return content, format, nil
+}
+
 func (c *Config) GetSensorConfigByID(id uint) (*SensorConfig, error) {
 	log.Printf("[CONFIG] Searching for sensor config with ID: %d", id)
 
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"errors"
 	"strings"
 	"testing"
 
@@ -8,6 +9,8 @@ import (
 )
 
 func TestSensorConfigValidate(t *testing.T) {
+	low, high := -20.0, 60.0
+
 	tests := []struct {
 		config     *SensorConfig
 		shouldPass bool
@@ -52,6 +55,78 @@ func TestSensorConfigValidate(t *testing.T) {
 			},
 			shouldPass: false,
 		},
+		{
+			config: &SensorConfig{
+				Name:    "NTC-1",
+				ID:      1,
+				Section: "Battery",
+				Module:  "Module 1",
+				Type:    4,
+			},
+			shouldPass: true,
+		},
+		{
+			config: &SensorConfig{
+				Name:     "NTC-1",
+				ID:       1,
+				Section:  "Battery",
+				Module:   "Module 1",
+				Encoding: "int16",
+			},
+			shouldPass: true,
+		},
+		{
+			config: &SensorConfig{
+				Name:    "NTC-1",
+				ID:      1,
+				Section: "Battery",
+				Module:  "Module 1",
+				Type:    999,
+			},
+			shouldPass: true,
+		},
+		{
+			config: &SensorConfig{
+				Name:     "NTC-1",
+				ID:       1,
+				Section:  "Battery",
+				Module:   "Module 1",
+				Encoding: "int128",
+			},
+			shouldPass: false,
+		},
+		{
+			config: &SensorConfig{
+				Name:     "Odometer",
+				ID:       1,
+				Section:  "Vehicle",
+				Module:   "Dash",
+				Encoding: "uint64",
+			},
+			shouldPass: false,
+		},
+		{
+			config: &SensorConfig{
+				Name:    "NTC-1",
+				ID:      1,
+				Section: "Battery",
+				Module:  "Module 1",
+				Min:     &low,
+				Max:     &high,
+			},
+			shouldPass: true,
+		},
+		{
+			config: &SensorConfig{
+				Name:    "NTC-1",
+				ID:      1,
+				Section: "Battery",
+				Module:  "Module 1",
+				Min:     &high,
+				Max:     &low,
+			},
+			shouldPass: false,
+		},
 	}
 
 	for _, test := range tests {
@@ -382,6 +457,180 @@ func TestNewConfigFromReader(t *testing.T) {
 	}
 }
 
+func TestNewConfigFromReader_ValidationErrors(t *testing.T) {
+	tests := []struct {
+		name         string
+		readerString string
+		expected     []ValidationError
+	}{
+		{
+			name: "collects every problem",
+			readerString: `
+		{
+			"sensors": [
+				{"name": "NTC-1", "id": 1, "section": "Battery", "module": "Module 1"},
+				{"name": "NTC-2", "id": 1, "section": "", "module": "Module 1", "encoding": "int99"},
+				{"name": "NTC-1", "id": 3, "section": "Battery", "module": "Module 1"},
+				{"name": "NTC-4", "id": 4, "section": "Battery", "module": ""}
+			],
+			"mqtt": [
+				{"username": "car", "password": "secret"},
+				{"username": "car", "password": ""}
+			]
+		}`,
+			expected: []ValidationError{
+				{Path: "sensors[1].section", Message: "empty"},
+				{Path: "sensors[1].encoding", Message: `unknown encoding "int99"`},
+				{Path: "sensors[1].id", Message: "duplicate id 1, already used by sensors[0]"},
+				{Path: "sensors[2]", Message: "duplicate sensor Battery/Module 1/NTC-1, already defined by sensors[0]"},
+				{Path: "sensors[3].module", Message: "empty"},
+				{Path: "mqtt[1].password", Message: "empty"},
+				{Path: "mqtt[1].username", Message: `duplicate username "car", already used by mqtt[0]`},
+			},
+		},
+		{
+			name: "unknown fields",
+			readerString: `
+		{
+			"sensors": [
+				{"name": "NTC-1", "id": 1, "section": "Battery", "module": "Module 1", "unti": "°C"}
+			],
+			"brokers": []
+		}`,
+			expected: []ValidationError{
+				{Path: "brokers", Message: "unknown field"},
+				{Path: "sensors[0].unti", Message: "unknown field"},
+			},
+		},
+		{
+			name: "wrong types",
+			readerString: `
+		{
+			"sensors": [
+				{"name": 1, "id": -1, "section": "Battery", "module": "Module 1", "min": "low"}
+			],
+			"mqtt": {}
+		}`,
+			expected: []ValidationError{
+				{Path: "mqtt", Message: "expected an array, got object"},
+				{Path: "sensors[0].id", Message: "expected a non-negative integer, got number"},
+				{Path: "sensors[0].min", Message: "expected a number, got string"},
+				{Path: "sensors[0].name", Message: "expected a string, got number"},
+			},
+		},
+		{
+			name: "acl filters",
+			readerString: `
+		{
+			"sensors": [],
+			"mqtt": [
+				{"username": "ecu", "password": "one", "publish": ["Battery/#", "Battery/#/NTC", ""], "subscribe": ["Dash/Bu+tons"]}
+			]
+		}`,
+			expected: []ValidationError{
+				{Path: "mqtt[0].publish[1]", Message: `invalid topic filter "Battery/#/NTC": '#' must be the whole last level`},
+				{Path: "mqtt[0].publish[2]", Message: "empty topic filter"},
+				{Path: "mqtt[0].subscribe[0]", Message: `invalid topic filter "Dash/Bu+tons": '+' must be a whole level`},
+			},
+		},
+		{
+			name: "password hash",
+			readerString: `
+		{
+			"sensors": [],
+			"mqtt": [
+				{"username": "ecu", "password": "$argon2id$v=19$m=0,t=2,p=1$c2FsdA$a2V5"},
+				{"username": "pit", "password": "$2b$10$short"}
+			]
+		}`,
+			expected: []ValidationError{
+				{Path: "mqtt[0].password", Message: `invalid argon2id hash: invalid parameters "m=0,t=2,p=1"`},
+				{Path: "mqtt[1].password", Message: "invalid bcrypt hash: crypto/bcrypt: hashedSecret too short to be a bcrypted password"},
+			},
+		},
+		{
+			name: "topic separators",
+			readerString: `
+		{
+			"sensors": [
+				{"name": "NTC/1", "id": 1, "section": "Battery#", "module": "Module 1"}
+			]
+		}`,
+			expected: []ValidationError{
+				{Path: "sensors[0].name", Message: `"NTC/1" must not contain '/', '+' or '#'`},
+				{Path: "sensors[0].section", Message: `"Battery#" must not contain '/', '+' or '#'`},
+			},
+		},
+		{
+			name: "reserved section",
+			readerString: `
+		{
+			"sensors": [
+				{"name": "Uptime", "id": 1, "section": "ephoros", "module": "Server"}
+			]
+		}`,
+			expected: []ValidationError{
+				{Path: "sensors[0].section", Message: `"ephoros" is reserved for server topics`},
+			},
+		},
+		{
+			name: "alerts",
+			readerString: `
+		{
+			"sensors": [
+				{"name": "NTC-1", "id": 1, "section": "Battery", "module": "Module 1"}
+			],
+			"alerts": [
+				{"name": "cell-overtemp", "section": "Battery", "module": "Module 1", "max": 60, "hysteresis": 2},
+				{"name": "cell-overtemp", "section": "Battery", "module": "Module 1", "sensor": "NTC-1", "stale": "2s"},
+				{"name": "cell/low", "section": "Battery", "module": "Module 1", "min": 10, "max": 5, "severity": "fatal"},
+				{"name": "ghost", "section": "Battery", "module": "Module 2", "max_rate": 0, "stale": "soon", "hysteresis": -1},
+				{"name": "idle", "section": "Battery", "module": "Module 1"}
+			]
+		}`,
+			expected: []ValidationError{
+				{Path: "alerts[1].name", Message: `duplicate alert "cell-overtemp", already defined by alerts[0]`},
+				{Path: "alerts[2].name", Message: `"cell/low" must not contain '/', '+' or '#'`},
+				{Path: "alerts[2].min", Message: "greater than max (10 > 5)"},
+				{Path: "alerts[2].severity", Message: `unknown severity "fatal", expected "info", "warning" or "critical"`},
+				{Path: "alerts[3].max_rate", Message: "must be positive, got 0"},
+				{Path: "alerts[3].stale", Message: `invalid duration "soon"`},
+				{Path: "alerts[3].hysteresis", Message: "must not be negative, got -1"},
+				{Path: "alerts[3]", Message: "matches no configured sensor"},
+				{Path: "alerts[4]", Message: "no condition, expected at least one of min, max, max_rate or stale"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		config, err := NewConfigFromReader(strings.NewReader(test.readerString))
+		assert.Nil(t, config, test.name)
+
+		var validationErrs ValidationErrors
+		if !errors.As(err, &validationErrs) {
+			t.Errorf("%s: expected ValidationErrors, got %v", test.name, err)
+			continue
+		}
+
+		assert.Equal(t, test.expected, []ValidationError(validationErrs), test.name)
+	}
+}
+
+func TestValidationErrors_Error(t *testing.T) {
+	single := ValidationErrors{{Path: "sensors[3].module", Message: "empty"}}
+	assert.Equal(t, "invalid configuration: sensors[3].module: empty", single.Error())
+
+	multiple := ValidationErrors{
+		{Path: "sensors[3].module", Message: "empty"},
+		{Path: "mqtt[0].password", Message: "empty"},
+	}
+	assert.Equal(t, "invalid configuration, 2 problems:\n  sensors[3].module: empty\n  mqtt[0].password: empty", multiple.Error())
+
+	var target ValidationError
+	assert.True(t, errors.As(error(multiple), &target))
+	assert.Equal(t, "sensors[3].module", target.Path)
+}
+
 func TestGetSensorConfigByID(t *testing.T) {
 	tests := []struct {
 		config     *Config
@@ -0,0 +1,191 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+type Format string
+
+const (
+	FormatJSON Format = "json"
+	FormatYAML Format = "yaml"
+	FormatTOML Format = "toml"
+)
+
+var tomlKeyValue = regexp.MustCompile(`^[A-Za-z0-9_\-."']+\s*=`)
+
+func ParseFormat(s string) (Format, error) {
+	switch strings.ToLower(s) {
+	case "json":
+		return FormatJSON, nil
+	case "yaml", "yml":
+		return FormatYAML, nil
+	case "toml":
+		return FormatTOML, nil
+	default:
+		return "", fmt.Errorf("unknown configuration format: %q", s)
+	}
+}
+
+func FormatFromPath(path string) (Format, bool) {
+	ext := strings.TrimPrefix(filepath.Ext(path), ".")
+	if ext == "" {
+		return "", false
+	}
+
+	format, err := ParseFormat(ext)
+	if err != nil {
+		return "", false
+	}
+
+	return format, true
+}
+
+func DetectFormat(content []byte) Format {
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "{"):
+			return FormatJSON
+		case strings.HasPrefix(line, "["), tomlKeyValue.MatchString(line):
+			return FormatTOML
+		default:
+			return FormatYAML
+		}
+	}
+
+	return FormatJSON
+}
+
+func DecodeConfig(content []byte, format Format) (*Config, error) {
+	raw, err := decodeRaw(content, format)
+	if err != nil {
+		log.Printf("[CONFIG] Error decoding %s configuration: %v", format, err)
+		return nil, err
+	}
+
+	if errs := checkShape("", raw, reflect.TypeOf(Config{})); len(errs) > 0 {
+		log.Printf("[CONFIG] Configuration has %d structural errors", len(errs))
+		return nil, ValidationErrors(errs)
+	}
+
+	normalized, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &Config{}
+	if err := json.Unmarshal(normalized, config); err != nil {
+		log.Printf("[CONFIG] Error decoding %s configuration: %v", format, err)
+		return nil, err
+	}
+
+	log.Printf("[CONFIG] %s decoded successfully - Sensors: %d, MQTT configs: %d",
+		strings.ToUpper(string(format)), len(config.SensorConfigs), len(config.MQTT))
+
+	return config, nil
+}
+
+func (c *Config) Encode(w io.Writer, format Format) error {
+	switch format {
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(c)
+	case FormatYAML:
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(c); err != nil {
+			return err
+		}
+		return encoder.Close()
+	case FormatTOML:
+		encoder := toml.NewEncoder(w)
+		encoder.Indent = ""
+		return encoder.Encode(c)
+	default:
+		return fmt.Errorf("unknown configuration format: %q", format)
+	}
+}
+
+func decodeRaw(content []byte, format Format) (any, error) {
+	var raw any
+
+	switch format {
+	case FormatJSON:
+		if err := json.Unmarshal(content, &raw); err != nil {
+			return nil, err
+		}
+		return raw, nil
+	case FormatYAML:
+		if err := yaml.Unmarshal(content, &raw); err != nil {
+			return nil, err
+		}
+	case FormatTOML:
+		table := map[string]any{}
+		if _, err := toml.Decode(string(content), &table); err != nil {
+			return nil, err
+		}
+		raw = table
+	default:
+		return nil, fmt.Errorf("unknown configuration format: %q", format)
+	}
+
+	return normalizeRaw(raw), nil
+}
+
+func normalizeRaw(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			v[key] = normalizeRaw(item)
+		}
+		return v
+	case map[any]any:
+		object := make(map[string]any, len(v))
+		for key, item := range v {
+			object[fmt.Sprint(key)] = normalizeRaw(item)
+		}
+		return object
+	case []map[string]any:
+		array := make([]any, len(v))
+		for i, item := range v {
+			array[i] = normalizeRaw(item)
+		}
+		return array
+	case []any:
+		for i, item := range v {
+			v[i] = normalizeRaw(item)
+		}
+		return v
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	case nil, string, bool, float64:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,218 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const yamlConfig = `
+# Battery pack thermistors
+sensors:
+  - name: NTC-1
+    id: 1
+    section: Battery
+    module: Module 1
+    encoding: scaled_int16
+    unit: °C
+    gain: 0.1
+    min: -20
+    max: 60
+  - name: Mode
+    id: 2
+    section: Inverter
+    module: Control
+    encoding: enum
+    states: [idle, drive, fault]
+
+mqtt:
+  - username: car
+    password: secret
+`
+
+const tomlConfig = `
+# Battery pack thermistors
+[[sensors]]
+name = "NTC-1"
+id = 1
+section = "Battery"
+module = "Module 1"
+encoding = "scaled_int16"
+unit = "°C"
+gain = 0.1
+min = -20
+max = 60
+
+[[sensors]]
+name = "Mode"
+id = 2
+section = "Inverter"
+module = "Control"
+encoding = "enum"
+states = ["idle", "drive", "fault"]
+
+[[mqtt]]
+username = "car"
+password = "secret"
+`
+
+const jsonConfig = `{
+	"sensors": [
+		{"name": "NTC-1", "id": 1, "section": "Battery", "module": "Module 1", "encoding": "scaled_int16",
+		 "unit": "°C", "gain": 0.1, "min": -20, "max": 60},
+		{"name": "Mode", "id": 2, "section": "Inverter", "module": "Control", "encoding": "enum",
+		 "states": ["idle", "drive", "fault"]}
+	],
+	"mqtt": [{"username": "car", "password": "secret"}]
+}`
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		content  string
+		expected Format
+	}{
+		{content: jsonConfig, expected: FormatJSON},
+		{content: yamlConfig, expected: FormatYAML},
+		{content: tomlConfig, expected: FormatTOML},
+		{content: "# comment\n\nsensors = []\n", expected: FormatTOML},
+		{content: "---\nsensors: []\n", expected: FormatYAML},
+		{content: "", expected: FormatJSON},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, DetectFormat([]byte(test.content)), test.content)
+	}
+}
+
+func TestFormatFromPath(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected Format
+		ok       bool
+	}{
+		{path: "config.json", expected: FormatJSON, ok: true},
+		{path: "config.yaml", expected: FormatYAML, ok: true},
+		{path: "/etc/ephoros/config.YML", expected: FormatYAML, ok: true},
+		{path: "config.toml", expected: FormatTOML, ok: true},
+		{path: "config", ok: false},
+		{path: "config.txt", ok: false},
+	}
+
+	for _, test := range tests {
+		format, ok := FormatFromPath(test.path)
+		assert.Equal(t, test.ok, ok, test.path)
+		assert.Equal(t, test.expected, format, test.path)
+	}
+}
+
+func TestNewConfigFromReader_Formats(t *testing.T) {
+	expected, err := NewConfigFromReader(strings.NewReader(jsonConfig))
+	assert.NoError(t, err)
+
+	for _, content := range []string{yamlConfig, tomlConfig} {
+		config, err := NewConfigFromReader(strings.NewReader(content))
+		assert.NoError(t, err)
+		assert.Equal(t, expected, config)
+	}
+
+	config, err := NewConfigFromReaderWithFormat(strings.NewReader(yamlConfig), FormatYAML)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, config)
+}
+
+func TestNewConfigFromReader_FormatErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		format   Format
+		expected []ValidationError
+	}{
+		{
+			name:    "yaml unknown field",
+			content: "sensors:\n  - name: NTC-1\n    id: 1\n    section: Battery\n    module: M1\n    unti: V\n",
+			format:  FormatYAML,
+			expected: []ValidationError{
+				{Path: "sensors[0].unti", Message: "unknown field"},
+			},
+		},
+		{
+			name:    "toml wrong type",
+			content: "[[sensors]]\nname = \"NTC-1\"\nid = \"one\"\nsection = \"Battery\"\nmodule = \"M1\"\n",
+			format:  FormatTOML,
+			expected: []ValidationError{
+				{Path: "sensors[0].id", Message: "expected a non-negative integer, got string"},
+			},
+		},
+		{
+			name:    "yaml duplicate id",
+			content: "sensors:\n  - {name: A, id: 1, section: S, module: M}\n  - {name: B, id: 1, section: S, module: M}\n",
+			format:  FormatYAML,
+			expected: []ValidationError{
+				{Path: "sensors[1].id", Message: "duplicate id 1, already used by sensors[0]"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		_, err := NewConfigFromReaderWithFormat(strings.NewReader(test.content), test.format)
+
+		var validationErrs ValidationErrors
+		if !errors.As(err, &validationErrs) {
+			t.Errorf("%s: expected ValidationErrors, got %v", test.name, err)
+			continue
+		}
+
+		assert.Equal(t, test.expected, []ValidationError(validationErrs), test.name)
+	}
+
+	_, err := NewConfigFromReaderWithFormat(strings.NewReader("[[sensors]\n"), FormatTOML)
+	assert.Error(t, err)
+
+	_, err = NewConfigFromReaderWithFormat(strings.NewReader("sensors: [\n"), FormatYAML)
+	assert.Error(t, err)
+}
+
+func TestConfigEncode_RoundTrip(t *testing.T) {
+	original, err := DecodeConfig([]byte(jsonConfig), FormatJSON)
+	assert.NoError(t, err)
+
+	for _, format := range []Format{FormatJSON, FormatYAML, FormatTOML} {
+		buf := &bytes.Buffer{}
+		assert.NoError(t, original.Encode(buf, format), format)
+
+		assert.Equal(t, format, DetectFormat(buf.Bytes()), format)
+
+		decoded, err := DecodeConfig(buf.Bytes(), format)
+		assert.NoError(t, err, format)
+		assert.Equal(t, original, decoded, format)
+	}
+
+	assert.Error(t, original.Encode(&bytes.Buffer{}, Format("xml")))
+}
+
+func TestNewConfigFromFile_Formats(t *testing.T) {
+	dir := t.TempDir()
+
+	files := map[string]string{
+		"config.yml":  yamlConfig,
+		"config.toml": tomlConfig,
+		"config":      tomlConfig,
+	}
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal("cannot write config file")
+		}
+
+		config, err := NewConfigFromFile(path)
+		assert.NoError(t, err, name)
+		assert.Len(t, config.SensorConfigs, 2, name)
+		assert.Len(t, config.MQTT, 1, name)
+	}
+}
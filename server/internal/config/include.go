@@ -0,0 +1,208 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+type origin struct {
+	file  string
+	dir   string
+	index int
+}
+
+func (o origin) path(kind string) string {
+	return fmt.Sprintf("%s[%d]", kind, o.index)
+}
+
+func (o origin) ref(kind string, from origin) string {
+	if o.file == from.file || o.file == "" {
+		return o.path(kind)
+	}
+
+	return o.path(kind) + " in " + o.file
+}
+
+func (c *Config) sensorOrigin(i int) origin {
+	if i < len(c.sensorOrigins) {
+		return c.sensorOrigins[i]
+	}
+
+	return origin{index: i}
+}
+
+func (c *Config) mqttOrigin(i int) origin {
+	if i < len(c.mqttOrigins) {
+		return c.mqttOrigins[i]
+	}
+
+	return origin{index: i}
+}
+
+func (c *Config) alertOrigin(i int) origin {
+	if i < len(c.alertOrigins) {
+		return c.alertOrigins[i]
+	}
+
+	return origin{index: i}
+}
+
+func (c *Config) Sources() []string {
+	return slices.Clone(c.sources)
+}
+
+type includeLoader struct {
+	root    string
+	stack   []string
+	sources []string
+}
+
+func (l *includeLoader) loadFile(path string) (*Config, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if slices.Contains(l.stack, abs) {
+		cycle := make([]string, 0, len(l.stack)+1)
+		for _, file := range append(l.stack[slices.Index(l.stack, abs):], abs) {
+			cycle = append(cycle, l.display(file))
+		}
+		return nil, fmt.Errorf("include cycle: %s", strings.Join(cycle, " -> "))
+	}
+
+	l.stack = append(l.stack, abs)
+	defer func() { l.stack = l.stack[:len(l.stack)-1] }()
+
+	content, format, err := readConfigFile(abs)
+	if err != nil {
+		return nil, err
+	}
+
+	display := l.display(abs)
+
+	config, err := DecodeConfig(content, format)
+	if err != nil {
+		var validationErrs ValidationErrors
+		if errors.As(err, &validationErrs) {
+			for i := range validationErrs {
+				validationErrs[i].File = display
+			}
+			return nil, validationErrs
+		}
+
+		return nil, fmt.Errorf("%s: %w", display, err)
+	}
+
+	config.setOrigins(display, filepath.Dir(abs))
+
+	if err := l.resolve(config, filepath.Dir(abs), display); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+func (l *includeLoader) resolve(config *Config, dir, display string) error {
+	includes := config.Include
+	config.Include = nil
+
+	for i, pattern := range includes {
+		full := pattern
+		if !filepath.IsAbs(full) {
+			full = filepath.Join(dir, full)
+		}
+		l.sources = append(l.sources, full)
+
+		matches, err := filepath.Glob(full)
+		if err != nil {
+			return ValidationErrors{{File: display, Path: fmt.Sprintf("include[%d]", i), Message: fmt.Sprintf("invalid pattern %q", pattern)}}
+		}
+
+		if len(matches) == 0 {
+			if !hasGlobMeta(pattern) {
+				return ValidationErrors{{File: display, Path: fmt.Sprintf("include[%d]", i), Message: fmt.Sprintf("file %q not found", pattern)}}
+			}
+
+			log.Printf("[CONFIG] Include pattern %q matched no files", pattern)
+			continue
+		}
+
+		for _, match := range matches {
+			log.Printf("[CONFIG] Including configuration from %s", l.display(match))
+
+			included, err := l.loadFile(match)
+			if err != nil {
+				return err
+			}
+
+			if included.Broker != nil {
+				return ValidationErrors{{File: l.display(match), Path: "broker", Message: "only allowed in the main configuration file"}}
+			}
+
+			config.merge(included)
+		}
+	}
+
+	return nil
+}
+
+func (l *includeLoader) display(path string) string {
+	if rel, err := filepath.Rel(l.root, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+
+	return path
+}
+
+func (c *Config) setOrigins(file, dir string) {
+	c.sensorOrigins = make([]origin, len(c.SensorConfigs))
+	for i := range c.SensorConfigs {
+		c.sensorOrigins[i] = origin{file: file, dir: dir, index: i}
+	}
+
+	c.mqttOrigins = make([]origin, len(c.MQTT))
+	for i := range c.MQTT {
+		c.mqttOrigins[i] = origin{file: file, dir: dir, index: i}
+	}
+
+	c.alertOrigins = make([]origin, len(c.Alerts))
+	for i := range c.Alerts {
+		c.alertOrigins[i] = origin{file: file, dir: dir, index: i}
+	}
+}
+
+func (c *Config) merge(other *Config) {
+	for len(c.sensorOrigins) < len(c.SensorConfigs) {
+		c.sensorOrigins = append(c.sensorOrigins, origin{index: len(c.sensorOrigins)})
+	}
+	for len(c.mqttOrigins) < len(c.MQTT) {
+		c.mqttOrigins = append(c.mqttOrigins, origin{index: len(c.mqttOrigins)})
+	}
+	for len(c.alertOrigins) < len(c.Alerts) {
+		c.alertOrigins = append(c.alertOrigins, origin{index: len(c.alertOrigins)})
+	}
+
+	for i := range other.SensorConfigs {
+		c.SensorConfigs = append(c.SensorConfigs, other.SensorConfigs[i])
+		c.sensorOrigins = append(c.sensorOrigins, other.sensorOrigin(i))
+	}
+
+	for i := range other.MQTT {
+		c.MQTT = append(c.MQTT, other.MQTT[i])
+		c.mqttOrigins = append(c.mqttOrigins, other.mqttOrigin(i))
+	}
+
+	for i := range other.Alerts {
+		c.Alerts = append(c.Alerts, other.Alerts[i])
+		c.alertOrigins = append(c.alertOrigins, other.alertOrigin(i))
+	}
+}
+
+func hasGlobMeta(pattern string) bool {
+	return strings.ContainsAny(pattern, `*?[\`)
+}
@@ -0,0 +1,228 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeConfigFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal("cannot create config directory")
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal("cannot write config file")
+		}
+	}
+}
+
+func TestNewConfigFromFile_Include(t *testing.T) {
+	dir := t.TempDir()
+	writeConfigFiles(t, dir, map[string]string{
+		"config.yaml": `
+include:
+  - sections/*.yaml
+  - users.json
+sensors:
+  - {name: Current, id: 1, section: Battery, module: Pack}
+`,
+		"sections/battery.yaml": `
+sensors:
+  - {name: "NTC-{1..4}", id: 100, section: Battery, module: "Module {1..2}", encoding: scaled_int16, id_stride: [10, 1]}
+`,
+		"sections/cooling.yaml": `
+include:
+  - ../pumps/pump.toml
+sensors:
+  - {name: Inlet, id: 200, section: Cooling, module: Radiator}
+`,
+		"pumps/pump.toml": `
+[[sensors]]
+name = "Speed"
+id = 300
+section = "Cooling"
+module = "Pump"
+`,
+		"users.json": `{"mqtt": [{"username": "car", "password": "secret"}]}`,
+	})
+
+	config, err := NewConfigFromFile(filepath.Join(dir, "config.yaml"))
+	assert.NoError(t, err)
+	assert.Nil(t, config.Include)
+
+	paths := make([]string, 0, len(config.SensorConfigs))
+	for _, sConfig := range config.SensorConfigs {
+		paths = append(paths, sConfig.Path())
+	}
+
+	assert.Equal(t, []string{
+		"Battery/Pack/Current",
+		"Battery/Module 1/NTC-1", "Battery/Module 1/NTC-2", "Battery/Module 1/NTC-3", "Battery/Module 1/NTC-4",
+		"Battery/Module 2/NTC-1", "Battery/Module 2/NTC-2", "Battery/Module 2/NTC-3", "Battery/Module 2/NTC-4",
+		"Cooling/Radiator/Inlet",
+		"Cooling/Pump/Speed",
+	}, paths)
+	assert.Len(t, config.MQTT, 1)
+
+	assert.Equal(t, []string{
+		filepath.Join(dir, "config.yaml"),
+		filepath.Join(dir, "sections/*.yaml"),
+		filepath.Join(dir, "sections/../pumps/pump.toml"),
+		filepath.Join(dir, "users.json"),
+	}, config.Sources())
+}
+
+func TestNewConfigFromFile_IncludeErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		expected string
+	}{
+		{
+			name: "cycle",
+			files: map[string]string{
+				"config.yaml": "include: [a.yaml]\nsensors: []\n",
+				"a.yaml":      "include: [b.yaml]\n",
+				"b.yaml":      "include: [a.yaml]\n",
+			},
+			expected: "include cycle: a.yaml -> b.yaml -> a.yaml",
+		},
+		{
+			name: "self include",
+			files: map[string]string{
+				"config.yaml": "include: [config.yaml]\nsensors: []\n",
+			},
+			expected: "include cycle: config.yaml -> config.yaml",
+		},
+		{
+			name: "missing file",
+			files: map[string]string{
+				"config.yaml": "include: [missing.yaml]\nsensors: []\n",
+			},
+			expected: `invalid configuration: config.yaml: include[0]: file "missing.yaml" not found`,
+		},
+		{
+			name: "invalid fragment",
+			files: map[string]string{
+				"config.yaml": "include: [a.yaml]\nsensors: []\n",
+				"a.yaml":      "sensors:\n  - {name: A, id: 1, section: S, module: M, unti: V}\n",
+			},
+			expected: "invalid configuration: a.yaml: sensors[0].unti: unknown field",
+		},
+		{
+			name: "duplicate across files",
+			files: map[string]string{
+				"config.yaml": "include: [a.yaml]\nsensors:\n  - {name: A, id: 1, section: S, module: M}\n",
+				"a.yaml":      "sensors:\n  - {name: B, id: 2, section: S, module: M}\n  - {name: A, id: 3, section: S, module: M}\n",
+			},
+			expected: "invalid configuration: a.yaml: sensors[1]: duplicate sensor S/M/A, already defined by sensors[0] in config.yaml",
+		},
+	}
+
+	for _, test := range tests {
+		dir := t.TempDir()
+		writeConfigFiles(t, dir, test.files)
+
+		_, err := NewConfigFromFile(filepath.Join(dir, "config.yaml"))
+		assert.EqualError(t, err, test.expected, test.name)
+	}
+
+	dir := t.TempDir()
+	writeConfigFiles(t, dir, map[string]string{
+		"config.yaml": "include: [a.yaml]\nsensors: []\n",
+		"a.yaml":      "sensors:\n  - {name: A, id: 1, section: S, module: \"\"}\n",
+	})
+
+	_, err := NewConfigFromFile(filepath.Join(dir, "config.yaml"))
+
+	var validationErrs ValidationErrors
+	assert.True(t, errors.As(err, &validationErrs))
+	assert.Equal(t, ValidationErrors{
+		{File: "a.yaml", Path: "sensors[0].module", Message: "empty"},
+	}, validationErrs)
+}
+
+func TestNewConfigFromFile_IncludeEmptyGlob(t *testing.T) {
+	dir := t.TempDir()
+	writeConfigFiles(t, dir, map[string]string{
+		"config.yaml": "include: [sections/*.yaml]\nsensors:\n  - {name: A, id: 1, section: S, module: M}\n",
+	})
+
+	config, err := NewConfigFromFile(filepath.Join(dir, "config.yaml"))
+	assert.NoError(t, err)
+	assert.Len(t, config.SensorConfigs, 1)
+}
+
+func TestWatcher_IncludedFileChange(t *testing.T) {
+	dir := t.TempDir()
+	writeConfigFiles(t, dir, map[string]string{
+		"config.yaml":           "include: [sections/*.yaml]\nsensors: []\n",
+		"sections/battery.yaml": "sensors:\n  - {name: A, id: 1, section: S, module: M}\n",
+	})
+
+	path := filepath.Join(dir, "config.yaml")
+	initial, err := NewConfigFromFile(path)
+	assert.NoError(t, err)
+
+	var applied *Config
+	w := NewWatcher(&WatcherConfig{
+		Path:   path,
+		Config: initial,
+		OnChange: func(cfg *Config) error {
+			applied = cfg
+			return nil
+		},
+	})
+
+	w.check()
+	assert.Nil(t, applied)
+
+	writeConfigFiles(t, dir, map[string]string{
+		"sections/cooling.yaml": "sensors:\n  - {name: B, id: 2, section: C, module: M}\n",
+	})
+
+	w.check()
+	assert.NotNil(t, applied)
+	assert.Len(t, applied.SensorConfigs, 2)
+
+	applied = nil
+	writeConfigFiles(t, dir, map[string]string{
+		"sections/battery.yaml": "sensors:\n  - {name: A, id: 1, section: S, module: M, unit: V}\n",
+	})
+
+	w.check()
+	assert.NotNil(t, applied)
+	assert.Equal(t, "V", applied.SensorConfigs[0].Unit)
+}
+
+func TestNewConfigFromFile_IncludeAlerts(t *testing.T) {
+	dir := t.TempDir()
+	writeConfigFiles(t, dir, map[string]string{
+		"config.yaml": `
+include: [alerts.yaml]
+sensors:
+  - {name: "NTC-{1..2}", id: 1, section: Battery, module: Module 1}
+alerts:
+  - {name: cell-overtemp, section: Battery, module: Module 1, max: 60}
+`,
+		"alerts.yaml": `
+alerts:
+  - {name: cell-overtemp, section: Battery, module: Module 1, sensor: NTC-2, stale: 1s}
+  - {name: pump-silent, section: Cooling, module: Pump, stale: 1s}
+`,
+	})
+
+	_, err := NewConfigFromFile(filepath.Join(dir, "config.yaml"))
+
+	var validationErrs ValidationErrors
+	assert.True(t, errors.As(err, &validationErrs))
+	assert.Equal(t, ValidationErrors{
+		{File: "alerts.yaml", Path: "alerts[0].name", Message: `duplicate alert "cell-overtemp", already defined by alerts[0] in config.yaml`},
+		{File: "alerts.yaml", Path: "alerts[1]", Message: "matches no configured sensor"},
+	}, validationErrs)
+}
@@ -3,14 +3,17 @@ package config
 import (
 	"fmt"
 	"log"
+	"sync"
 	"time"
 
 	"github.com/ApexCorse/ephoros/server/internal/db"
 )
 
 type ConfigManager struct {
+	mu     sync.Mutex
 	config *Config
 	db     *db.DB
+	cache  *db.SensorCache
 }
 
 func NewConfigManager(config *Config, db *db.DB) *ConfigManager {
@@ -39,36 +42,170 @@ func NewConfigManager(config *Config, db *db.DB) *ConfigManager {
 	return manager
 }
 
+func (m *ConfigManager) SetSensorCache(cache *db.SensorCache) {
+	m.cache = cache
+}
+
+func (m *ConfigManager) Config() *Config {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.config
+}
+
+func (m *ConfigManager) Reload(cfg *Config) (*Plan, error) {
+	log.Println("[CONFIG] Applying reloaded configuration")
+
+	if cfg == nil {
+		log.Println("[CONFIG] Error: reloaded configuration is nil")
+		return nil, fmt.Errorf("no configuration available")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	previous := m.config
+	m.config = cfg
+
+	plan, err := m.sync()
+	if err != nil {
+		log.Println("[CONFIG] Reload failed, restoring previous configuration")
+		m.config = previous
+		return nil, err
+	}
+
+	return plan, nil
+}
+
+func (m *ConfigManager) Plan(previous *Config) (*Plan, error) {
+	log.Println("[CONFIG] Computing configuration plan")
+
+	if m.config == nil {
+		log.Println("[CONFIG] Error: No configuration available for planning")
+		return nil, fmt.Errorf("no configuration available")
+	}
+
+	state, err := loadState(m.db)
+	if err != nil {
+		log.Printf("[CONFIG] Error loading database state: %v", err)
+		return nil, err
+	}
+
+	plan := buildPlan(m.config, state)
+
+	if previous != nil {
+		plan.Changes = append(plan.Changes, diffMQTTUsers(previous, m.config)...)
+	} else if len(m.config.MQTT) > 0 {
+		plan.Warnings = append(plan.Warnings, "MQTT users are not stored in the database and were not compared, "+
+			"pass the deployed configuration with -current to see removed or changed users")
+	}
+
+	log.Printf("[CONFIG] Plan computed - Changes: %d, Destructive: %t", len(plan.Changes), plan.Destructive())
+	return plan, nil
+}
+
 func (m *ConfigManager) UpdateDB() error {
-	log.Println("[CONFIG] Starting database update from configuration")
+	_, err := m.Sync()
+	return err
+}
+
+func (m *ConfigManager) Sync() (*Plan, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.sync()
+}
+
+func (m *ConfigManager) sync() (*Plan, error) {
+	log.Println("[CONFIG] Starting database sync from configuration")
 
 	if m.config == nil {
 		log.Println("[CONFIG] Error: No configuration available for database update")
-		return fmt.Errorf("no configuration available")
+		return nil, fmt.Errorf("no configuration available")
 	}
 
-	for i, sConfig := range m.config.SensorConfigs {
-		log.Printf("[CONFIG] Processing sensor config %d/%d - Name: %s, Section: %s, Module: %s",
-			i+1, len(m.config.SensorConfigs), sConfig.Name, sConfig.Section, sConfig.Module)
-
-		err := m.createSensorIfNotExists(sConfig.Section, sConfig.Module, sConfig.Name)
+	var plan *Plan
+	err := m.db.Transaction(func(tx *db.DB) error {
+		state, err := loadState(tx)
 		if err != nil {
-			log.Printf("[CONFIG] Error creating sensor - Name: %s, Section: %s, Module: %s, Error: %v",
-				sConfig.Name, sConfig.Section, sConfig.Module, err)
+			log.Printf("[CONFIG] Error loading database state: %v", err)
 			return err
 		}
 
-		log.Printf("[CONFIG] Successfully processed sensor config - Name: %s", sConfig.Name)
+		plan = buildPlan(m.config, state)
+		logPlan(plan)
+
+		return m.applyPlan(tx, plan)
+	})
+	if err != nil {
+		log.Printf("[CONFIG] Database sync failed, rolled back: %v", err)
+		return nil, err
+	}
+
+	if !plan.Empty() && m.cache != nil {
+		log.Println("[CONFIG] Sensor hierarchy changed, refreshing sensor cache")
+
+		if err := m.cache.Refresh(); err != nil {
+			log.Printf("[CONFIG] Error refreshing sensor cache: %v", err)
+			return nil, err
+		}
+	}
+
+	log.Printf("[CONFIG] Database sync completed successfully - Changes: %d", len(plan.Changes))
+	return plan, nil
+}
+
+func logPlan(plan *Plan) {
+	if plan.Empty() {
+		log.Println("[CONFIG] Database already matches configuration")
+		return
+	}
+
+	for _, change := range plan.Changes {
+		if change.Previous != "" {
+			log.Printf("[CONFIG] Plan: %s %s %s (was %s) %v",
+				change.Action, change.Kind, change.Path(), change.Previous, change.Fields)
+		} else {
+			log.Printf("[CONFIG] Plan: %s %s %s %v", change.Action, change.Kind, change.Path(), change.Fields)
+		}
+	}
+}
+
+func (m *ConfigManager) applyPlan(tx *db.DB, plan *Plan) error {
+	now := time.Now()
+
+	for i, change := range plan.Changes {
+		log.Printf("[CONFIG] Applying change %d/%d - %s %s %s",
+			i+1, len(plan.Changes), change.Action, change.Kind, change.Path())
+
+		var err error
+		switch {
+		case change.Kind == KindSection:
+			_, err = createSectionIfNotExists(tx, change.Section)
+		case change.Kind == KindModule:
+			_, err = createModuleIfNotExists(tx, change.Section, change.Module)
+		case change.Action == ActionCreate:
+			err = createSensor(tx, change.sensor)
+		case change.Action == ActionUpdate:
+			err = updateSensor(tx, change.SensorID, change.sensor)
+		case change.Action == ActionArchive:
+			err = tx.ArchiveSensor(change.SensorID, now)
+		}
+
+		if err != nil {
+			log.Printf("[CONFIG] Error applying change - %s %s %s, Error: %v",
+				change.Action, change.Kind, change.Path(), err)
+			return err
+		}
 	}
 
-	log.Println("[CONFIG] Database update completed successfully")
 	return nil
 }
 
-func (m *ConfigManager) createSectionIfNotExists(sectionName string) (*db.Section, error) {
+func createSectionIfNotExists(d *db.DB, sectionName string) (*db.Section, error) {
 	log.Printf("[CONFIG] Checking if section exists: %s", sectionName)
 
-	section, err := m.db.GetSectionByName(sectionName)
+	section, err := d.GetSectionByName(sectionName)
 
 	if err != nil {
 		log.Printf("[CONFIG] Section not found, creating new section: %s", sectionName)
@@ -77,7 +214,7 @@ func (m *ConfigManager) createSectionIfNotExists(sectionName string) (*db.Sectio
 			Name: sectionName,
 		}
 
-		err = m.db.InsertSection(section)
+		err = d.InsertSection(section)
 		if err != nil {
 			log.Printf("[CONFIG] Error creating section - Name: %s, Error: %v", sectionName, err)
 			return nil, err
@@ -91,17 +228,17 @@ func (m *ConfigManager) createSectionIfNotExists(sectionName string) (*db.Sectio
 	return section, nil
 }
 
-func (m *ConfigManager) createModuleIfNotExists(sectionName, moduleName string) (*db.Module, error) {
+func createModuleIfNotExists(d *db.DB, sectionName, moduleName string) (*db.Module, error) {
 	log.Printf("[CONFIG] Creating module if not exists - Section: %s, Module: %s", sectionName, moduleName)
 
-	section, err := m.createSectionIfNotExists(sectionName)
+	section, err := createSectionIfNotExists(d, sectionName)
 	if err != nil {
 		log.Printf("[CONFIG] Error creating section for module - Section: %s, Module: %s, Error: %v",
 			sectionName, moduleName, err)
 		return nil, err
 	}
 
-	module, err := m.db.GetModuleByNameAndSection(sectionName, moduleName)
+	module, err := d.GetModuleByNameAndSection(sectionName, moduleName)
 
 	if err != nil {
 		log.Printf("[CONFIG] Module not found, creating new module - Section: %s, Module: %s", sectionName, moduleName)
@@ -111,7 +248,7 @@ func (m *ConfigManager) createModuleIfNotExists(sectionName, moduleName string)
 			SectionID: section.ID,
 		}
 
-		err = m.db.InsertModule(module)
+		err = d.InsertModule(module)
 		if err != nil {
 			log.Printf("[CONFIG] Error creating module - Section: %s, Module: %s, Error: %v",
 				sectionName, moduleName, err)
@@ -128,41 +265,57 @@ func (m *ConfigManager) createModuleIfNotExists(sectionName, moduleName string)
 	return module, nil
 }
 
-func (m *ConfigManager) createSensorIfNotExists(sectionName, moduleName, sensorName string) error {
-	log.Printf("[CONFIG] Creating sensor if not exists - Section: %s, Module: %s, Sensor: %s",
-		sectionName, moduleName, sensorName)
-
-	module, err := m.createModuleIfNotExists(sectionName, moduleName)
+func createSensor(d *db.DB, sConfig *SensorConfig) error {
+	module, err := createModuleIfNotExists(d, sConfig.Section, sConfig.Module)
 	if err != nil {
-		log.Printf("[CONFIG] Error creating module for sensor - Section: %s, Module: %s, Sensor: %s, Error: %v",
-			sectionName, moduleName, sensorName, err)
 		return err
 	}
 
-	_, err = m.db.GetSensorByNameAndModuleAndSection(sectionName, moduleName, sensorName, time.Now(), time.Now())
+	sensor := sensorFromConfig(sConfig, module.ID)
 
+	err = d.InsertSensor(sensor)
 	if err != nil {
-		log.Printf("[CONFIG] Sensor not found, creating new sensor - Section: %s, Module: %s, Sensor: %s",
-			sectionName, moduleName, sensorName)
+		return err
+	}
 
-		sensor := &db.Sensor{
-			Name:     sensorName,
-			ModuleID: module.ID,
-		}
+	log.Printf("[CONFIG] Sensor created successfully - ID: %d, Name: %s, ModuleID: %d",
+		sensor.ID, sensor.Name, sensor.ModuleID)
+	return nil
+}
 
-		err = m.db.InsertSensor(sensor)
-		if err != nil {
-			log.Printf("[CONFIG] Error creating sensor - Section: %s, Module: %s, Sensor: %s, Error: %v",
-				sectionName, moduleName, sensorName, err)
-			return err
-		}
+func updateSensor(d *db.DB, sensorID uint, sConfig *SensorConfig) error {
+	module, err := createModuleIfNotExists(d, sConfig.Section, sConfig.Module)
+	if err != nil {
+		return err
+	}
 
-		log.Printf("[CONFIG] Sensor created successfully - ID: %d, Name: %s, ModuleID: %d",
-			sensor.ID, sensor.Name, sensor.ModuleID)
-	} else {
-		log.Printf("[CONFIG] Sensor already exists - Section: %s, Module: %s, Sensor: %s",
-			sectionName, moduleName, sensorName)
+	sensor := sensorFromConfig(sConfig, module.ID)
+	sensor.ID = sensorID
+
+	err = d.UpdateSensor(sensor)
+	if err != nil {
+		return err
 	}
 
+	log.Printf("[CONFIG] Sensor updated successfully - ID: %d, Name: %s, ModuleID: %d",
+		sensor.ID, sensor.Name, sensor.ModuleID)
 	return nil
 }
+
+func sensorFromConfig(sConfig *SensorConfig, moduleID uint) *db.Sensor {
+	valueType, _ := sConfig.ValueType()
+
+	return &db.Sensor{
+		Name:        sConfig.Name,
+		ModuleID:    moduleID,
+		ConfigID:    sConfig.ID,
+		Type:        valueType,
+		Unit:        sConfig.Unit,
+		Description: sConfig.Description,
+		Min:         sConfig.Min,
+		Max:         sConfig.Max,
+		Gain:        sConfig.Gain,
+		Offset:      sConfig.Offset,
+		States:      sConfig.States,
+	}
+}
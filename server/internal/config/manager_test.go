@@ -71,3 +71,277 @@ func TestUpdateDB(t *testing.T) {
 	assert.Equal(t, "NTC-3", sensors[2].Name)
 	assert.Equal(t, modules[2].ID, sensors[2].ModuleID)
 }
+
+func TestUpdateDB_RefreshesSensorCache(t *testing.T) {
+	gormDb, cleanUp, err := db.TestDB()
+	if err != nil {
+		t.Fatal("cannot setup db")
+	}
+	defer cleanUp()
+
+	database := db.NewDB(gormDb)
+	cache := db.NewSensorCache(database)
+
+	config := &Config{
+		SensorConfigs: []SensorConfig{
+			{
+				Name:    "NTC-1",
+				Module:  "Module 1",
+				Section: "Battery",
+				ID:      1,
+			},
+		},
+	}
+
+	configManager := NewConfigManager(config, database)
+	configManager.SetSensorCache(cache)
+
+	err = configManager.UpdateDB()
+	assert.Nil(t, err)
+
+	id, ok := cache.Lookup("Battery", "Module 1", "NTC-1")
+	assert.True(t, ok)
+	assert.NotZero(t, id)
+
+	err = configManager.UpdateDB()
+	assert.Nil(t, err)
+
+	sensors := make([]db.Sensor, 0)
+	gormDb.Find(&sensors)
+	assert.Len(t, sensors, 1)
+}
+
+func TestUpdateDB_PersistsSensorMetadata(t *testing.T) {
+	gormDb, cleanUp, err := db.TestDB()
+	if err != nil {
+		t.Fatal("cannot setup db")
+	}
+	defer cleanUp()
+
+	min, max := -20.0, 60.0
+	config := &Config{
+		SensorConfigs: []SensorConfig{
+			{
+				Name:        "NTC-1",
+				Module:      "Module 1",
+				Section:     "Battery",
+				ID:          42,
+				Encoding:    "scaled_int16",
+				Unit:        "°C",
+				Description: "Cell temperature",
+				Min:         &min,
+				Max:         &max,
+				Gain:        0.1,
+			},
+		},
+	}
+
+	configManager := NewConfigManager(config, db.NewDB(gormDb))
+	err = configManager.UpdateDB()
+	assert.Nil(t, err)
+
+	sensor := &db.Sensor{}
+	gormDb.Where("name = ?", "NTC-1").First(sensor)
+
+	assert.Equal(t, uint(42), sensor.ConfigID)
+	assert.Equal(t, db.TypeScaledInt16, sensor.Type)
+	assert.Equal(t, "°C", sensor.Unit)
+	assert.Equal(t, "Cell temperature", sensor.Description)
+	assert.Equal(t, min, *sensor.Min)
+	assert.Equal(t, max, *sensor.Max)
+	assert.Equal(t, 0.1, sensor.Gain)
+
+	config.SensorConfigs[0].Unit = "K"
+	config.SensorConfigs[0].Max = nil
+
+	err = configManager.UpdateDB()
+	assert.Nil(t, err)
+
+	updated := &db.Sensor{}
+	gormDb.Where("name = ?", "NTC-1").First(updated)
+
+	assert.Equal(t, sensor.ID, updated.ID)
+	assert.Equal(t, "K", updated.Unit)
+	assert.Nil(t, updated.Max)
+}
+
+func TestSync_RenameAndArchive(t *testing.T) {
+	gormDb, cleanUp, err := db.TestDB()
+	if err != nil {
+		t.Fatal("cannot setup db")
+	}
+	defer cleanUp()
+
+	database := db.NewDB(gormDb)
+	cache := db.NewSensorCache(database)
+
+	config := &Config{
+		SensorConfigs: []SensorConfig{
+			{
+				Name:    "NTC-1",
+				Module:  "Module 1",
+				Section: "Battery",
+				ID:      1,
+			},
+			{
+				Name:    "NTC-2",
+				Module:  "Module 1",
+				Section: "Battery",
+				ID:      2,
+			},
+		},
+	}
+
+	configManager := NewConfigManager(config, database)
+	configManager.SetSensorCache(cache)
+
+	plan, err := configManager.Sync()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, plan.Count(ActionCreate, KindSensor))
+
+	original := &db.Sensor{}
+	gormDb.Where("config_id = ?", 1).First(original)
+	gormDb.Create(&db.Record{SensorID: original.ID, Value: 42})
+
+	config.SensorConfigs = []SensorConfig{
+		{
+			Name:    "Cell temperature 1",
+			Module:  "Module 1",
+			Section: "Battery",
+			ID:      1,
+		},
+	}
+
+	plan, err = configManager.Sync()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, plan.Count(ActionUpdate, KindSensor))
+	assert.Equal(t, 1, plan.Count(ActionArchive, KindSensor))
+
+	renamed := &db.Sensor{}
+	gormDb.Where("config_id = ?", 1).First(renamed)
+	assert.Equal(t, original.ID, renamed.ID)
+	assert.Equal(t, "Cell temperature 1", renamed.Name)
+
+	var records int64
+	gormDb.Model(&db.Record{}).Where("sensor_id = ?", renamed.ID).Count(&records)
+	assert.Equal(t, int64(1), records)
+
+	archived := &db.Sensor{}
+	gormDb.Where("config_id = ?", 2).First(archived)
+	assert.NotNil(t, archived.ArchivedAt)
+
+	_, ok := cache.Lookup("Battery", "Module 1", "NTC-2")
+	assert.False(t, ok)
+	id, ok := cache.Lookup("Battery", "Module 1", "Cell temperature 1")
+	assert.True(t, ok)
+	assert.Equal(t, original.ID, id)
+
+	plan, err = configManager.Sync()
+	assert.Nil(t, err)
+	assert.True(t, plan.Empty())
+}
+
+func TestPlan_DoesNotWrite(t *testing.T) {
+	gormDb, cleanUp, err := db.TestDB()
+	if err != nil {
+		t.Fatal("cannot setup db")
+	}
+	defer cleanUp()
+
+	config := &Config{
+		SensorConfigs: []SensorConfig{
+			{
+				Name:    "NTC-1",
+				Module:  "Module 1",
+				Section: "Battery",
+				ID:      1,
+			},
+		},
+		MQTT: []MQTTUserConfig{
+			{Username: "ecu", Password: "secret"},
+		},
+	}
+
+	previous := &Config{
+		MQTT: []MQTTUserConfig{
+			{Username: "logger", Password: "secret"},
+		},
+	}
+
+	plan, err := NewConfigManager(config, db.NewDB(gormDb)).Plan(previous)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, plan.Count(ActionCreate, KindSection))
+	assert.Equal(t, 1, plan.Count(ActionCreate, KindModule))
+	assert.Equal(t, 1, plan.Count(ActionCreate, KindSensor))
+	assert.Equal(t, 1, plan.Count(ActionCreate, KindMQTTUser))
+	assert.Equal(t, 1, plan.Count(ActionRemove, KindMQTTUser))
+	assert.True(t, plan.Destructive())
+	assert.Empty(t, plan.Warnings)
+
+	plan, err = NewConfigManager(config, db.NewDB(gormDb)).Plan(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, plan.Count(ActionCreate, KindMQTTUser))
+	assert.Len(t, plan.Warnings, 1)
+	assert.Contains(t, plan.String(), "MQTT users are not stored in the database")
+
+	var sensors int64
+	gormDb.Model(&db.Sensor{}).Count(&sensors)
+	assert.Equal(t, int64(0), sensors)
+}
+
+func TestReload(t *testing.T) {
+	gormDb, cleanUp, err := db.TestDB()
+	if err != nil {
+		t.Fatal("cannot setup db")
+	}
+	defer cleanUp()
+
+	database := db.NewDB(gormDb)
+	cache := db.NewSensorCache(database)
+
+	config := &Config{
+		SensorConfigs: []SensorConfig{
+			{
+				Name:    "NTC-1",
+				Module:  "Module 1",
+				Section: "Battery",
+				ID:      1,
+			},
+		},
+	}
+
+	configManager := NewConfigManager(config, database)
+	configManager.SetSensorCache(cache)
+
+	err = configManager.UpdateDB()
+	assert.Nil(t, err)
+
+	reloaded := &Config{
+		SensorConfigs: []SensorConfig{
+			{
+				Name:    "NTC-1",
+				Module:  "Module 1",
+				Section: "Battery",
+				ID:      1,
+			},
+			{
+				Name:    "NTC-2",
+				Module:  "Module 1",
+				Section: "Battery",
+				ID:      2,
+			},
+		},
+	}
+
+	plan, err := configManager.Reload(reloaded)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, plan.Count(ActionCreate, KindSensor))
+	assert.Same(t, reloaded, configManager.Config())
+
+	_, ok := cache.Lookup("Battery", "Module 1", "NTC-2")
+	assert.True(t, ok)
+
+	_, err = configManager.Reload(nil)
+	assert.Error(t, err)
+	assert.Same(t, reloaded, configManager.Config())
+}
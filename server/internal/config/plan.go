@@ -0,0 +1,420 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/ApexCorse/ephoros/server/internal/db"
+)
+
+type ChangeAction string
+
+const (
+	ActionCreate  ChangeAction = "create"
+	ActionUpdate  ChangeAction = "update"
+	ActionArchive ChangeAction = "archive"
+	ActionRemove  ChangeAction = "remove"
+)
+
+type ChangeKind string
+
+const (
+	KindSection  ChangeKind = "section"
+	KindModule   ChangeKind = "module"
+	KindSensor   ChangeKind = "sensor"
+	KindMQTTUser ChangeKind = "mqtt_user"
+)
+
+type Change struct {
+	Action   ChangeAction `json:"action"`
+	Kind     ChangeKind   `json:"kind"`
+	Section  string       `json:"section,omitempty"`
+	Module   string       `json:"module,omitempty"`
+	Name     string       `json:"name,omitempty"`
+	ConfigID uint         `json:"config_id,omitempty"`
+	SensorID uint         `json:"sensor_id,omitempty"`
+	Previous string       `json:"previous,omitempty"`
+	Fields   []string     `json:"fields,omitempty"`
+
+	sensor *SensorConfig
+}
+
+func (c *Change) Path() string {
+	switch c.Kind {
+	case KindSection:
+		return c.Section
+	case KindModule:
+		return c.Section + "/" + c.Module
+	case KindMQTTUser:
+		return c.Name
+	default:
+		return c.Section + "/" + c.Module + "/" + c.Name
+	}
+}
+
+type Plan struct {
+	Changes  []Change `json:"changes"`
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+func (p *Plan) Empty() bool {
+	return len(p.Changes) == 0
+}
+
+func (p *Plan) Destructive() bool {
+	for _, change := range p.Changes {
+		if change.Action == ActionArchive || change.Action == ActionRemove {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *Plan) String() string {
+	b := &strings.Builder{}
+
+	if p.Empty() {
+		b.WriteString("No changes. The database matches the configuration.\n")
+	} else {
+		p.writeChanges(b)
+	}
+
+	for _, warning := range p.Warnings {
+		fmt.Fprintf(b, "\nWarning: %s\n", warning)
+	}
+
+	return b.String()
+}
+
+func (p *Plan) writeChanges(b *strings.Builder) {
+	created, updated, removed := 0, 0, 0
+	for _, change := range p.Changes {
+		switch change.Action {
+		case ActionCreate:
+			created++
+		case ActionUpdate:
+			updated++
+		default:
+			removed++
+		}
+	}
+
+	fmt.Fprintf(b, "Plan: %d to create, %d to update, %d to archive or remove\n\n", created, updated, removed)
+
+	for _, change := range p.Changes {
+		symbol := "+"
+		switch change.Action {
+		case ActionUpdate:
+			symbol = "~"
+		case ActionArchive, ActionRemove:
+			symbol = "-"
+		}
+
+		fmt.Fprintf(b, "  %s %-9s %s", symbol, change.Kind, change.Path())
+
+		if change.ConfigID != 0 {
+			fmt.Fprintf(b, " (id %d)", change.ConfigID)
+		}
+		if change.Previous != "" {
+			fmt.Fprintf(b, ", was %s", change.Previous)
+		}
+		if change.Action == ActionArchive {
+			b.WriteString(", orphaned")
+		}
+		if len(change.Fields) > 0 {
+			fmt.Fprintf(b, ": %s", strings.Join(change.Fields, ", "))
+		}
+
+		b.WriteString("\n")
+	}
+}
+
+func (p *Plan) Count(action ChangeAction, kind ChangeKind) int {
+	n := 0
+	for _, change := range p.Changes {
+		if change.Action == action && change.Kind == kind {
+			n++
+		}
+	}
+
+	return n
+}
+
+type dbState struct {
+	sections []db.Section
+	modules  []db.Module
+	sensors  []db.Sensor
+}
+
+func loadState(d *db.DB) (*dbState, error) {
+	sections, err := d.GetSections()
+	if err != nil {
+		return nil, err
+	}
+
+	modules, err := d.GetModules()
+	if err != nil {
+		return nil, err
+	}
+
+	sensors, err := d.GetSensors()
+	if err != nil {
+		return nil, err
+	}
+
+	return &dbState{
+		sections: sections,
+		modules:  modules,
+		sensors:  sensors,
+	}, nil
+}
+
+func buildPlan(cfg *Config, state *dbState) *Plan {
+	plan := &Plan{Changes: make([]Change, 0)}
+
+	sectionNames := make(map[uint]string, len(state.sections))
+	sectionExists := make(map[string]bool, len(state.sections))
+	for _, section := range state.sections {
+		sectionNames[section.ID] = section.Name
+		sectionExists[section.Name] = true
+	}
+
+	modulePaths := make(map[uint][2]string, len(state.modules))
+	moduleExists := make(map[[2]string]bool, len(state.modules))
+	for _, module := range state.modules {
+		path := [2]string{sectionNames[module.SectionID], module.Name}
+		modulePaths[module.ID] = path
+		moduleExists[path] = true
+	}
+
+	configIDs := make(map[uint]bool, len(cfg.SensorConfigs))
+	for _, sConfig := range cfg.SensorConfigs {
+		if sConfig.ID != 0 {
+			configIDs[sConfig.ID] = true
+		}
+	}
+
+	byConfigID := make(map[uint]*db.Sensor)
+	byPath := make(map[string]*db.Sensor)
+	for i := range state.sensors {
+		sensor := &state.sensors[i]
+
+		if sensor.ConfigID != 0 {
+			if current, ok := byConfigID[sensor.ConfigID]; !ok || (current.ArchivedAt != nil && sensor.ArchivedAt == nil) {
+				byConfigID[sensor.ConfigID] = sensor
+			}
+		}
+
+		if sensor.ArchivedAt == nil {
+			modulePath := modulePaths[sensor.ModuleID]
+			byPath[modulePath[0]+"/"+modulePath[1]+"/"+sensor.Name] = sensor
+		}
+	}
+
+	sensorChanges := make([]Change, 0)
+	matched := make(map[uint]bool)
+
+	for i := range cfg.SensorConfigs {
+		sConfig := &cfg.SensorConfigs[i]
+
+		if !sectionExists[sConfig.Section] {
+			sectionExists[sConfig.Section] = true
+			plan.Changes = append(plan.Changes, Change{
+				Action:  ActionCreate,
+				Kind:    KindSection,
+				Section: sConfig.Section,
+			})
+		}
+
+		modulePath := [2]string{sConfig.Section, sConfig.Module}
+		if !moduleExists[modulePath] {
+			moduleExists[modulePath] = true
+			plan.Changes = append(plan.Changes, Change{
+				Action:  ActionCreate,
+				Kind:    KindModule,
+				Section: sConfig.Section,
+				Module:  sConfig.Module,
+			})
+		}
+
+		var existing *db.Sensor
+		if sConfig.ID != 0 {
+			if sensor, ok := byConfigID[sConfig.ID]; ok && !matched[sensor.ID] {
+				existing = sensor
+			}
+		}
+
+		if existing == nil {
+			if sensor, ok := byPath[sConfig.Path()]; ok && !matched[sensor.ID] &&
+				(sensor.ConfigID == 0 || sensor.ConfigID == sConfig.ID || !configIDs[sensor.ConfigID]) {
+				existing = sensor
+			}
+		}
+
+		change := Change{
+			Kind:     KindSensor,
+			Section:  sConfig.Section,
+			Module:   sConfig.Module,
+			Name:     sConfig.Name,
+			ConfigID: sConfig.ID,
+			sensor:   sConfig,
+		}
+
+		if existing == nil {
+			change.Action = ActionCreate
+			sensorChanges = append(sensorChanges, change)
+			continue
+		}
+
+		matched[existing.ID] = true
+
+		existingModule := modulePaths[existing.ModuleID]
+		existingPath := existingModule[0] + "/" + existingModule[1] + "/" + existing.Name
+
+		fields := changedSensorFields(existing, existingModule, sConfig)
+		if len(fields) == 0 {
+			continue
+		}
+
+		change.Action = ActionUpdate
+		change.SensorID = existing.ID
+		change.Fields = fields
+		if existingPath != sConfig.Path() {
+			change.Previous = existingPath
+		}
+
+		sensorChanges = append(sensorChanges, change)
+	}
+
+	for _, sensor := range state.sensors {
+		if sensor.ArchivedAt != nil || matched[sensor.ID] {
+			continue
+		}
+
+		modulePath := modulePaths[sensor.ModuleID]
+		sensorChanges = append(sensorChanges, Change{
+			Action:   ActionArchive,
+			Kind:     KindSensor,
+			Section:  modulePath[0],
+			Module:   modulePath[1],
+			Name:     sensor.Name,
+			ConfigID: sensor.ConfigID,
+			SensorID: sensor.ID,
+		})
+	}
+
+	plan.Changes = append(plan.Changes, sensorChanges...)
+	return plan
+}
+
+func diffMQTTUsers(previous, current *Config) []Change {
+	changes := make([]Change, 0)
+
+	previousUsers := make(map[string]MQTTUserConfig, len(previous.MQTT))
+	for _, user := range previous.MQTT {
+		previousUsers[user.Username] = user
+	}
+
+	currentUsers := make(map[string]bool, len(current.MQTT))
+	for _, user := range current.MQTT {
+		currentUsers[user.Username] = true
+
+		old, ok := previousUsers[user.Username]
+		if !ok {
+			changes = append(changes, Change{
+				Action: ActionCreate,
+				Kind:   KindMQTTUser,
+				Name:   user.Username,
+			})
+			continue
+		}
+
+		fields := make([]string, 0)
+		if old.Password != user.Password {
+			fields = append(fields, "password")
+		}
+		if !slices.Equal(old.Publish, user.Publish) {
+			fields = append(fields, "publish")
+		}
+		if !slices.Equal(old.Subscribe, user.Subscribe) {
+			fields = append(fields, "subscribe")
+		}
+
+		if len(fields) > 0 {
+			changes = append(changes, Change{
+				Action: ActionUpdate,
+				Kind:   KindMQTTUser,
+				Name:   user.Username,
+				Fields: fields,
+			})
+		}
+	}
+
+	for _, user := range previous.MQTT {
+		if !currentUsers[user.Username] {
+			changes = append(changes, Change{
+				Action: ActionRemove,
+				Kind:   KindMQTTUser,
+				Name:   user.Username,
+			})
+		}
+	}
+
+	return changes
+}
+
+func changedSensorFields(existing *db.Sensor, existingModule [2]string, sConfig *SensorConfig) []string {
+	fields := make([]string, 0)
+
+	if existing.ArchivedAt != nil {
+		fields = append(fields, "archived")
+	}
+	if existing.Name != sConfig.Name {
+		fields = append(fields, "name")
+	}
+	if existingModule[0] != sConfig.Section {
+		fields = append(fields, "section")
+	}
+	if existingModule[1] != sConfig.Module {
+		fields = append(fields, "module")
+	}
+	if existing.ConfigID != sConfig.ID {
+		fields = append(fields, "config_id")
+	}
+	if valueType, _ := sConfig.ValueType(); existing.Type != valueType {
+		fields = append(fields, "type")
+	}
+	if existing.Unit != sConfig.Unit {
+		fields = append(fields, "unit")
+	}
+	if existing.Description != sConfig.Description {
+		fields = append(fields, "description")
+	}
+	if !equalFloatPtr(existing.Min, sConfig.Min) {
+		fields = append(fields, "min")
+	}
+	if !equalFloatPtr(existing.Max, sConfig.Max) {
+		fields = append(fields, "max")
+	}
+	if existing.Gain != sConfig.Gain {
+		fields = append(fields, "gain")
+	}
+	if existing.Offset != sConfig.Offset {
+		fields = append(fields, "offset")
+	}
+	if !slices.Equal(existing.States, sConfig.States) {
+		fields = append(fields, "states")
+	}
+
+	return fields
+}
+
+func equalFloatPtr(a, b *float64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return *a == *b
+}
@@ -0,0 +1,226 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ApexCorse/ephoros/server/internal/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func testState() *dbState {
+	archivedAt := time.Now()
+
+	return &dbState{
+		sections: []db.Section{
+			{ID: 1, Name: "Battery"},
+			{ID: 2, Name: "Vehicle"},
+		},
+		modules: []db.Module{
+			{ID: 1, Name: "Module 1", SectionID: 1},
+			{ID: 2, Name: "Module 2", SectionID: 1},
+			{ID: 3, Name: "ECU", SectionID: 2},
+		},
+		sensors: []db.Sensor{
+			{ID: 1, Name: "NTC-1", ModuleID: 1, ConfigID: 1},
+			{ID: 2, Name: "NTC-2", ModuleID: 1, ConfigID: 2},
+			{ID: 3, Name: "Speed", ModuleID: 3, ConfigID: 0},
+			{ID: 4, Name: "Old", ModuleID: 3, ConfigID: 4, ArchivedAt: &archivedAt},
+		},
+	}
+}
+
+func TestBuildPlan_EmptyDatabase(t *testing.T) {
+	cfg := &Config{
+		SensorConfigs: []SensorConfig{
+			{ID: 1, Name: "NTC-1", Section: "Battery", Module: "Module 1"},
+			{ID: 2, Name: "NTC-2", Section: "Battery", Module: "Module 1"},
+			{ID: 3, Name: "Speed", Section: "Vehicle", Module: "ECU"},
+		},
+	}
+
+	plan := buildPlan(cfg, &dbState{})
+
+	assert.Equal(t, 2, plan.Count(ActionCreate, KindSection))
+	assert.Equal(t, 2, plan.Count(ActionCreate, KindModule))
+	assert.Equal(t, 3, plan.Count(ActionCreate, KindSensor))
+	assert.Len(t, plan.Changes, 7)
+
+	assert.Equal(t, KindSection, plan.Changes[0].Kind)
+	assert.Equal(t, KindModule, plan.Changes[1].Kind)
+	assert.Equal(t, "Battery/Module 1", plan.Changes[1].Path())
+}
+
+func TestBuildPlan_InSync(t *testing.T) {
+	cfg := &Config{
+		SensorConfigs: []SensorConfig{
+			{ID: 1, Name: "NTC-1", Section: "Battery", Module: "Module 1"},
+			{ID: 2, Name: "NTC-2", Section: "Battery", Module: "Module 1"},
+			{ID: 3, Name: "Speed", Section: "Vehicle", Module: "ECU"},
+		},
+	}
+
+	plan := buildPlan(cfg, testState())
+
+	assert.Len(t, plan.Changes, 1)
+	assert.Equal(t, ActionUpdate, plan.Changes[0].Action)
+	assert.Equal(t, uint(3), plan.Changes[0].SensorID)
+	assert.Equal(t, []string{"config_id"}, plan.Changes[0].Fields)
+
+	state := testState()
+	state.sensors[2].ConfigID = 3
+
+	plan = buildPlan(cfg, state)
+	assert.True(t, plan.Empty())
+}
+
+func TestBuildPlan_RenameKeepsSensor(t *testing.T) {
+	cfg := &Config{
+		SensorConfigs: []SensorConfig{
+			{ID: 1, Name: "NTC-1-renamed", Section: "Battery", Module: "Module 2"},
+			{ID: 2, Name: "NTC-2", Section: "Battery", Module: "Module 1"},
+			{ID: 3, Name: "Speed", Section: "Vehicle", Module: "ECU"},
+		},
+	}
+
+	state := testState()
+	state.sensors[2].ConfigID = 3
+
+	plan := buildPlan(cfg, state)
+
+	assert.Len(t, plan.Changes, 1)
+	change := plan.Changes[0]
+	assert.Equal(t, ActionUpdate, change.Action)
+	assert.Equal(t, uint(1), change.SensorID)
+	assert.Equal(t, "Battery/Module 1/NTC-1", change.Previous)
+	assert.Equal(t, "Battery/Module 2/NTC-1-renamed", change.Path())
+	assert.Equal(t, []string{"name", "module"}, change.Fields)
+}
+
+func TestBuildPlan_ArchivesRemovedSensors(t *testing.T) {
+	cfg := &Config{
+		SensorConfigs: []SensorConfig{
+			{ID: 1, Name: "NTC-1", Section: "Battery", Module: "Module 1"},
+		},
+	}
+
+	plan := buildPlan(cfg, testState())
+
+	assert.Equal(t, 2, plan.Count(ActionArchive, KindSensor))
+	assert.Len(t, plan.Changes, 2)
+	assert.Equal(t, uint(2), plan.Changes[0].SensorID)
+	assert.Equal(t, uint(3), plan.Changes[1].SensorID)
+	assert.Equal(t, "Vehicle/ECU/Speed", plan.Changes[1].Path())
+}
+
+func TestBuildPlan_RestoresArchivedSensor(t *testing.T) {
+	cfg := &Config{
+		SensorConfigs: []SensorConfig{
+			{ID: 1, Name: "NTC-1", Section: "Battery", Module: "Module 1"},
+			{ID: 2, Name: "NTC-2", Section: "Battery", Module: "Module 1"},
+			{ID: 3, Name: "Speed", Section: "Vehicle", Module: "ECU"},
+			{ID: 4, Name: "Old", Section: "Vehicle", Module: "ECU"},
+		},
+	}
+
+	state := testState()
+	state.sensors[2].ConfigID = 3
+
+	plan := buildPlan(cfg, state)
+
+	assert.Len(t, plan.Changes, 1)
+	assert.Equal(t, ActionUpdate, plan.Changes[0].Action)
+	assert.Equal(t, uint(4), plan.Changes[0].SensorID)
+	assert.Equal(t, []string{"archived"}, plan.Changes[0].Fields)
+}
+
+func TestBuildPlan_MetadataChanges(t *testing.T) {
+	max := 60.0
+	cfg := &Config{
+		SensorConfigs: []SensorConfig{
+			{ID: 1, Name: "NTC-1", Section: "Battery", Module: "Module 1", Unit: "°C", Max: &max, Encoding: "int16"},
+			{ID: 2, Name: "NTC-2", Section: "Battery", Module: "Module 1"},
+			{ID: 3, Name: "Speed", Section: "Vehicle", Module: "ECU"},
+			{ID: 5, Name: "Torque", Section: "Vehicle", Module: "Inverter"},
+		},
+	}
+
+	state := testState()
+	state.sensors[2].ConfigID = 3
+
+	plan := buildPlan(cfg, state)
+
+	assert.Len(t, plan.Changes, 3)
+	assert.Equal(t, Change{Action: ActionCreate, Kind: KindModule, Section: "Vehicle", Module: "Inverter"}, plan.Changes[0])
+	assert.Equal(t, ActionUpdate, plan.Changes[1].Action)
+	assert.Equal(t, []string{"type", "unit", "max"}, plan.Changes[1].Fields)
+	assert.Equal(t, ActionCreate, plan.Changes[2].Action)
+	assert.Equal(t, "Vehicle/Inverter/Torque", plan.Changes[2].Path())
+}
+
+func TestDiffMQTTUsers(t *testing.T) {
+	previous := &Config{
+		MQTT: []MQTTUserConfig{
+			{Username: "ecu", Password: "one"},
+			{Username: "logger", Password: "two"},
+			{Username: "pit", Password: "three"},
+			{Username: "car", Password: "five", Publish: []string{"Battery/#"}},
+		},
+	}
+	current := &Config{
+		MQTT: []MQTTUserConfig{
+			{Username: "ecu", Password: "one"},
+			{Username: "pit", Password: "changed"},
+			{Username: "dashboard", Password: "four"},
+			{Username: "car", Password: "five", Publish: []string{"Battery/#", "Engine/#"}, Subscribe: []string{"#"}},
+		},
+	}
+
+	changes := diffMQTTUsers(previous, current)
+
+	assert.Equal(t, []Change{
+		{Action: ActionUpdate, Kind: KindMQTTUser, Name: "pit", Fields: []string{"password"}},
+		{Action: ActionCreate, Kind: KindMQTTUser, Name: "dashboard"},
+		{Action: ActionUpdate, Kind: KindMQTTUser, Name: "car", Fields: []string{"publish", "subscribe"}},
+		{Action: ActionRemove, Kind: KindMQTTUser, Name: "logger"},
+	}, changes)
+}
+
+func TestPlanDestructive(t *testing.T) {
+	plan := &Plan{Changes: []Change{
+		{Action: ActionCreate, Kind: KindSensor},
+		{Action: ActionUpdate, Kind: KindSensor},
+	}}
+	assert.False(t, plan.Destructive())
+
+	plan.Changes = append(plan.Changes, Change{Action: ActionRemove, Kind: KindMQTTUser})
+	assert.True(t, plan.Destructive())
+
+	plan = &Plan{Changes: []Change{{Action: ActionArchive, Kind: KindSensor}}}
+	assert.True(t, plan.Destructive())
+}
+
+func TestPlanString(t *testing.T) {
+	assert.Equal(t, "No changes. The database matches the configuration.\n", (&Plan{}).String())
+
+	plan := &Plan{Changes: []Change{
+		{Action: ActionCreate, Kind: KindSection, Section: "Battery"},
+		{Action: ActionCreate, Kind: KindSensor, Section: "Battery", Module: "Module 1", Name: "NTC-1", ConfigID: 1},
+		{Action: ActionUpdate, Kind: KindSensor, Section: "Battery", Module: "Module 1", Name: "NTC-2", ConfigID: 2,
+			Previous: "Battery/Module 1/Old", Fields: []string{"name", "unit"}},
+		{Action: ActionArchive, Kind: KindSensor, Section: "Vehicle", Module: "ECU", Name: "Speed"},
+		{Action: ActionRemove, Kind: KindMQTTUser, Name: "logger"},
+	}}
+
+	expected := "Plan: 2 to create, 1 to update, 2 to archive or remove\n\n" +
+		"  + section   Battery\n" +
+		"  + sensor    Battery/Module 1/NTC-1 (id 1)\n" +
+		"  ~ sensor    Battery/Module 1/NTC-2 (id 2), was Battery/Module 1/Old: name, unit\n" +
+		"  - sensor    Vehicle/ECU/Speed, orphaned\n" +
+		"  - mqtt_user logger\n"
+
+	assert.Equal(t, expected, plan.String())
+
+	plan = &Plan{Warnings: []string{"users not compared"}}
+	assert.Equal(t, "No changes. The database matches the configuration.\n\nWarning: users not compared\n", plan.String())
+}
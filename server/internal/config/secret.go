@@ -0,0 +1,141 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const secretFilePrefix = "file:"
+
+func (c *Config) interpolate() error {
+	errs := ValidationErrors{}
+
+	for i := range c.MQTT {
+		mConfig := &c.MQTT[i]
+		o := c.mqttOrigin(i)
+		path := o.path("mqtt")
+
+		username, err := c.resolveSecret(mConfig.Username, o.dir)
+		if err != nil {
+			errs = append(errs, ValidationError{File: o.file, Path: path + ".username", Message: err.Error()})
+		}
+
+		password, err := c.resolveSecret(mConfig.Password, o.dir)
+		if err != nil {
+			errs = append(errs, ValidationError{File: o.file, Path: path + ".password", Message: err.Error()})
+		}
+
+		mConfig.Username = username
+		mConfig.Password = password
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+func (c *Config) resolveSecret(value, dir string) (string, error) {
+	expanded, err := expandEnv(value)
+	if err != nil {
+		return "", err
+	}
+
+	if !strings.HasPrefix(expanded, secretFilePrefix) {
+		return expanded, nil
+	}
+
+	path := strings.TrimPrefix(expanded, secretFilePrefix)
+	if path == "" {
+		return "", fmt.Errorf("empty secret file path")
+	}
+
+	if !filepath.IsAbs(path) && dir != "" {
+		path = filepath.Join(dir, path)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("secret file %q not found", path)
+		}
+		return "", fmt.Errorf("cannot read secret file %q: %v", path, err)
+	}
+
+	if c.sources != nil {
+		c.sources = append(c.sources, path)
+	}
+
+	secret := strings.TrimRight(string(content), "\r\n")
+	if secret == "" {
+		return "", fmt.Errorf("secret file %q is empty", path)
+	}
+
+	log.Printf("[CONFIG] Resolved secret from file %s", path)
+	return secret, nil
+}
+
+func expandEnv(value string) (string, error) {
+	b := &strings.Builder{}
+
+	for i := 0; i < len(value); i++ {
+		if value[i] != '$' {
+			b.WriteByte(value[i])
+			continue
+		}
+
+		if i+1 < len(value) && value[i+1] == '$' {
+			b.WriteByte('$')
+			i++
+			continue
+		}
+
+		if i+1 >= len(value) || value[i+1] != '{' {
+			b.WriteByte('$')
+			continue
+		}
+
+		end := strings.IndexByte(value[i:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated variable reference")
+		}
+
+		expr := value[i+2 : i+end]
+		name, fallback, hasFallback := strings.Cut(expr, ":-")
+		if !validEnvName(name) {
+			return "", fmt.Errorf("invalid variable name %q", name)
+		}
+
+		resolved, ok := os.LookupEnv(name)
+		if !ok || (resolved == "" && hasFallback) {
+			if !hasFallback {
+				return "", fmt.Errorf("environment variable %q is not set", name)
+			}
+			resolved = fallback
+		}
+
+		b.WriteString(resolved)
+		i += end
+	}
+
+	return b.String(), nil
+}
+
+func validEnvName(name string) bool {
+	if name == "" {
+		return false
+	}
+
+	for i, r := range name {
+		if r == '_' || (r >= 'A' && r <= 'Z') || (r >= 'a' && r <= 'z') || (i > 0 && r >= '0' && r <= '9') {
+			continue
+		}
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,145 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandEnv(t *testing.T) {
+	t.Setenv("EPHOROS_USER", "car")
+	t.Setenv("EPHOROS_EMPTY", "")
+
+	tests := []struct {
+		value       string
+		expected    string
+		expectError bool
+	}{
+		{value: "plain", expected: "plain"},
+		{value: "${EPHOROS_USER}", expected: "car"},
+		{value: "user-${EPHOROS_USER}-1", expected: "user-car-1"},
+		{value: "${EPHOROS_USER}${EPHOROS_USER}", expected: "carcar"},
+		{value: "${EPHOROS_MISSING:-fallback}", expected: "fallback"},
+		{value: "${EPHOROS_EMPTY:-fallback}", expected: "fallback"},
+		{value: "${EPHOROS_EMPTY}", expected: ""},
+		{value: "pa$$word", expected: "pa$word"},
+		{value: "$${EPHOROS_USER}", expected: "${EPHOROS_USER}"},
+		{value: "cost$5", expected: "cost$5"},
+		{value: "${EPHOROS_MISSING}", expectError: true},
+		{value: "${EPHOROS_USER", expectError: true},
+		{value: "${}", expectError: true},
+		{value: "${1ABC}", expectError: true},
+	}
+
+	for _, test := range tests {
+		expanded, err := expandEnv(test.value)
+
+		if test.expectError {
+			assert.Error(t, err, test.value)
+			continue
+		}
+
+		assert.NoError(t, err, test.value)
+		assert.Equal(t, test.expected, expanded, test.value)
+	}
+}
+
+func TestNewConfigFromFile_Secrets(t *testing.T) {
+	t.Setenv("EPHOROS_CAR_USER", "car")
+	t.Setenv("EPHOROS_CAR_PASSWORD", "hunter2")
+	t.Setenv("EPHOROS_SECRETS", "secrets")
+
+	dir := t.TempDir()
+	writeConfigFiles(t, dir, map[string]string{
+		"config.yaml": `
+include: [users/pit.yaml]
+sensors: []
+mqtt:
+  - username: ${EPHOROS_CAR_USER}
+    password: ${EPHOROS_CAR_PASSWORD}
+  - username: telemetry
+    password: file:${EPHOROS_SECRETS}/telemetry
+`,
+		"users/pit.yaml": `
+mqtt:
+  - username: pit
+    password: file:pit.secret
+`,
+		"users/pit.secret":  "wall\n",
+		"secrets/telemetry": "s3cr3t\r\n",
+	})
+
+	config, err := NewConfigFromFile(filepath.Join(dir, "config.yaml"))
+	assert.NoError(t, err)
+
+	assert.Equal(t, []MQTTUserConfig{
+		{Username: "car", Password: "hunter2"},
+		{Username: "telemetry", Password: "s3cr3t"},
+		{Username: "pit", Password: "wall"},
+	}, config.MQTT)
+
+	assert.Contains(t, config.Sources(), filepath.Join(dir, "secrets/telemetry"))
+	assert.Contains(t, config.Sources(), filepath.Join(dir, "users/pit.secret"))
+
+	raw, err := DecodeConfig([]byte("mqtt:\n  - {username: car, password: \"${EPHOROS_CAR_PASSWORD}\"}\n"), FormatYAML)
+	assert.NoError(t, err)
+	assert.Equal(t, "${EPHOROS_CAR_PASSWORD}", raw.MQTT[0].Password)
+}
+
+func TestNewConfigFromFile_SecretErrors(t *testing.T) {
+	t.Setenv("EPHOROS_SET_PASSWORD", "hunter2")
+
+	dir := t.TempDir()
+	writeConfigFiles(t, dir, map[string]string{
+		"config.yaml": `
+sensors: []
+mqtt:
+  - {username: car, password: "${EPHOROS_UNSET_PASSWORD}"}
+  - {username: pit, password: "file:missing.secret"}
+  - {username: telemetry, password: "file:empty.secret"}
+  - {username: "${EPHOROS_SET_PASSWORD}", password: ""}
+`,
+		"empty.secret": "\n",
+	})
+
+	_, err := NewConfigFromFile(filepath.Join(dir, "config.yaml"))
+
+	assert.Equal(t, ValidationErrors{
+		{File: "config.yaml", Path: "mqtt[0].password", Message: `environment variable "EPHOROS_UNSET_PASSWORD" is not set`},
+		{File: "config.yaml", Path: "mqtt[1].password", Message: fmt.Sprintf("secret file %q not found", filepath.Join(dir, "missing.secret"))},
+		{File: "config.yaml", Path: "mqtt[2].password", Message: fmt.Sprintf("secret file %q is empty", filepath.Join(dir, "empty.secret"))},
+	}, err)
+
+	assert.NotContains(t, err.Error(), "hunter2")
+}
+
+func TestMQTTUserConfig_Redacted(t *testing.T) {
+	user := MQTTUserConfig{Username: "car", Password: "hunter2"}
+
+	for _, formatted := range []string{
+		fmt.Sprintf("%v", user),
+		fmt.Sprintf("%+v", user),
+		fmt.Sprintf("%#v", user),
+		fmt.Sprintf("%v", &Config{MQTT: []MQTTUserConfig{user}}),
+	} {
+		assert.NotContains(t, formatted, "hunter2")
+		assert.True(t, strings.Contains(formatted, "car"), formatted)
+	}
+}
+
+func TestNewConfigFromReader_HashedPasswordsNotInterpolated(t *testing.T) {
+	bcryptHash := "$2a$04$Ew6OhO1pKqS8QzT1Q1Ao5ucVNjZ0vKb1r4fM0oQY0wH6i0eXnS8pW"
+	argon2idHash := "$argon2id$v=19$m=19456,t=2,p=1$c2FsdA$a2V5"
+
+	config, err := NewConfigFromReader(strings.NewReader(fmt.Sprintf(`
+mqtt:
+  - {username: car, password: '%s'}
+  - {username: pit, password: '%s'}
+`, bcryptHash, argon2idHash)))
+	assert.NoError(t, err)
+	assert.Equal(t, bcryptHash, config.MQTT[0].Password)
+	assert.Equal(t, argon2idHash, config.MQTT[1].Password)
+}
@@ -0,0 +1,209 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+type expansion struct {
+	value   string
+	indices []int
+}
+
+func expandPattern(pattern string) ([]expansion, error) {
+	start := strings.Index(pattern, "{")
+	if start < 0 {
+		if strings.Contains(pattern, "}") {
+			return nil, fmt.Errorf("unmatched '}' in %q", pattern)
+		}
+		return []expansion{{value: pattern}}, nil
+	}
+
+	length := strings.Index(pattern[start:], "}")
+	if length < 0 {
+		return nil, fmt.Errorf("unmatched '{' in %q", pattern)
+	}
+	end := start + length
+
+	alternatives, err := expandGroup(pattern[start+1 : end])
+	if err != nil {
+		return nil, fmt.Errorf("invalid group in %q: %w", pattern, err)
+	}
+
+	rest, err := expandPattern(pattern[end+1:])
+	if err != nil {
+		return nil, err
+	}
+
+	expanded := make([]expansion, 0, len(alternatives)*len(rest))
+	for i, alternative := range alternatives {
+		for _, suffix := range rest {
+			expanded = append(expanded, expansion{
+				value:   pattern[:start] + alternative + suffix.value,
+				indices: append([]int{i}, suffix.indices...),
+			})
+		}
+	}
+
+	return expanded, nil
+}
+
+func expandGroup(group string) ([]string, error) {
+	if strings.Contains(group, "{") {
+		return nil, fmt.Errorf("nested groups are not supported")
+	}
+
+	if from, to, ok := strings.Cut(group, ".."); ok {
+		return expandRange(from, to)
+	}
+
+	alternatives := strings.Split(group, ",")
+	if len(alternatives) < 2 {
+		return nil, fmt.Errorf("expected a range {a..b} or a list {a,b}, got {%s}", group)
+	}
+
+	for _, alternative := range alternatives {
+		if alternative == "" {
+			return nil, fmt.Errorf("empty alternative in {%s}", group)
+		}
+	}
+
+	return alternatives, nil
+}
+
+func expandRange(from, to string) ([]string, error) {
+	first, err := strconv.Atoi(from)
+	if err != nil || first < 0 {
+		return nil, fmt.Errorf("invalid range start %q", from)
+	}
+
+	last, err := strconv.Atoi(to)
+	if err != nil || last < first {
+		return nil, fmt.Errorf("invalid range end %q", to)
+	}
+
+	width := 0
+	if len(from) > 1 && from[0] == '0' {
+		width = len(from)
+	}
+
+	values := make([]string, 0, last-first+1)
+	for i := first; i <= last; i++ {
+		values = append(values, fmt.Sprintf("%0*d", width, i))
+	}
+
+	return values, nil
+}
+
+func isTemplate(sConfig *SensorConfig) bool {
+	return strings.ContainsAny(sConfig.Section+sConfig.Module+sConfig.Name, "{}")
+}
+
+func (c *Config) expandTemplates() error {
+	errs := ValidationErrors{}
+
+	sensors := make([]SensorConfig, 0, len(c.SensorConfigs))
+	origins := make([]origin, 0, len(c.SensorConfigs))
+	for i := range c.SensorConfigs {
+		sConfig := c.SensorConfigs[i]
+		o := c.sensorOrigin(i)
+
+		if !isTemplate(&sConfig) {
+			sensors = append(sensors, sConfig)
+			origins = append(origins, o)
+			continue
+		}
+
+		generated, err := expandSensorTemplate(&sConfig)
+		if err != nil {
+			errs = append(errs, ValidationError{File: o.file, Path: o.path("sensors"), Message: err.Error()})
+			continue
+		}
+
+		log.Printf("[CONFIG] Expanded sensor template %s into %d sensors", sConfig.Path(), len(generated))
+
+		sensors = append(sensors, generated...)
+		for range generated {
+			origins = append(origins, o)
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	c.SensorConfigs = sensors
+	c.sensorOrigins = origins
+	return nil
+}
+
+func expandSensorTemplate(template *SensorConfig) ([]SensorConfig, error) {
+	sections, err := expandPattern(template.Section)
+	if err != nil {
+		return nil, fmt.Errorf("section: %w", err)
+	}
+
+	modules, err := expandPattern(template.Module)
+	if err != nil {
+		return nil, fmt.Errorf("module: %w", err)
+	}
+
+	names, err := expandPattern(template.Name)
+	if err != nil {
+		return nil, fmt.Errorf("name: %w", err)
+	}
+
+	groups := len(sections[0].indices) + len(modules[0].indices) + len(names[0].indices)
+
+	strides := template.IDStride
+	if len(strides) == 0 && groups == 1 {
+		strides = []uint{1}
+	}
+
+	if template.ID != 0 && len(strides) != groups {
+		return nil, fmt.Errorf("id_stride must list one stride per group (%d), in section, module, name order", groups)
+	}
+
+	if template.ID == 0 && len(template.IDStride) > 0 {
+		return nil, fmt.Errorf("id_stride requires an id")
+	}
+
+	if slices.Contains(strides, 0) {
+		return nil, fmt.Errorf("id_stride values must be positive")
+	}
+
+	generated := make([]SensorConfig, 0, len(sections)*len(modules)*len(names))
+	ids := make(map[uint]string)
+	for _, section := range sections {
+		for _, module := range modules {
+			for _, name := range names {
+				sConfig := *template
+				sConfig.Section = section.value
+				sConfig.Module = module.value
+				sConfig.Name = name.value
+				sConfig.IDStride = nil
+
+				if template.ID != 0 {
+					indices := slices.Concat(section.indices, module.indices, name.indices)
+
+					sConfig.ID = template.ID
+					for i, index := range indices {
+						sConfig.ID += uint(index) * strides[i]
+					}
+
+					if other, ok := ids[sConfig.ID]; ok {
+						return nil, fmt.Errorf("id_stride %v gives %s and %s the same id %d", strides, other, sConfig.Path(), sConfig.ID)
+					}
+					ids[sConfig.ID] = sConfig.Path()
+				}
+
+				generated = append(generated, sConfig)
+			}
+		}
+	}
+
+	return generated, nil
+}
@@ -0,0 +1,169 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandPattern(t *testing.T) {
+	tests := []struct {
+		pattern     string
+		expected    []string
+		expectError bool
+	}{
+		{pattern: "NTC-1", expected: []string{"NTC-1"}},
+		{pattern: "NTC-{1..3}", expected: []string{"NTC-1", "NTC-2", "NTC-3"}},
+		{pattern: "NTC-{08..10}", expected: []string{"NTC-08", "NTC-09", "NTC-10"}},
+		{pattern: "{Front,Rear}", expected: []string{"Front", "Rear"}},
+		{pattern: "{L,R}-{1..2}", expected: []string{"L-1", "L-2", "R-1", "R-2"}},
+		{pattern: "NTC-{1..1}", expected: []string{"NTC-1"}},
+		{pattern: "NTC-{3..1}", expectError: true},
+		{pattern: "NTC-{a..3}", expectError: true},
+		{pattern: "NTC-{1..3", expectError: true},
+		{pattern: "NTC-1..3}", expectError: true},
+		{pattern: "NTC-{1}", expectError: true},
+		{pattern: "NTC-{a,}", expectError: true},
+		{pattern: "NTC-{{1..2}}", expectError: true},
+	}
+
+	for _, test := range tests {
+		expanded, err := expandPattern(test.pattern)
+
+		if test.expectError {
+			assert.Error(t, err, test.pattern)
+			continue
+		}
+
+		assert.NoError(t, err, test.pattern)
+
+		values := make([]string, 0, len(expanded))
+		for _, e := range expanded {
+			values = append(values, e.value)
+		}
+		assert.Equal(t, test.expected, values, test.pattern)
+	}
+
+	expanded, err := expandPattern("{L,R}-{1..2}")
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 0}, expanded[2].indices)
+}
+
+func TestNewConfigFromReader_Templates(t *testing.T) {
+	config, err := NewConfigFromReader(strings.NewReader(`
+sensors:
+  - name: Current
+    id: 1
+    section: Battery
+    module: Pack
+    type: 0
+  - name: NTC-{1..24}
+    id: 100
+    section: Battery
+    module: Module {1..6}
+    id_stride: [100, 1]
+    encoding: scaled_int16
+    unit: °C
+    gain: 0.1
+  - name: "{FL,FR,RL,RR}"
+    section: Wheels
+    module: Speed
+    type: 0
+`))
+	assert.NoError(t, err)
+	assert.Len(t, config.SensorConfigs, 1+24*6+4)
+
+	assert.Equal(t, "Battery/Pack/Current", config.SensorConfigs[0].Path())
+
+	first := config.SensorConfigs[1]
+	assert.Equal(t, "Battery/Module 1/NTC-1", first.Path())
+	assert.Equal(t, uint(100), first.ID)
+	assert.Equal(t, "°C", first.Unit)
+	assert.Equal(t, 0.1, first.Gain)
+
+	secondModule := config.SensorConfigs[1+24]
+	assert.Equal(t, "Battery/Module 2/NTC-1", secondModule.Path())
+	assert.Equal(t, uint(200), secondModule.ID)
+
+	last := config.SensorConfigs[24*6]
+	assert.Equal(t, "Battery/Module 6/NTC-24", last.Path())
+	assert.Equal(t, uint(623), last.ID)
+
+	wheel := config.SensorConfigs[1+24*6+3]
+	assert.Equal(t, "Wheels/Speed/RR", wheel.Path())
+	assert.Equal(t, uint(0), wheel.ID)
+}
+
+func TestNewConfigFromReader_TemplateStableIDs(t *testing.T) {
+	ids := func(nameRange string) map[string]uint {
+		config, err := NewConfigFromReader(strings.NewReader(`
+sensors:
+  - name: NTC-{1..` + nameRange + `}
+    id: 100
+    section: Battery
+    module: Module {1..2}
+    id_stride: [100, 1]
+`))
+		assert.NoError(t, err)
+
+		result := make(map[string]uint)
+		for _, sConfig := range config.SensorConfigs {
+			result[sConfig.Path()] = sConfig.ID
+		}
+		return result
+	}
+
+	before, after := ids("24"), ids("26")
+	assert.Len(t, before, 48)
+	assert.Len(t, after, 52)
+
+	for path, id := range before {
+		assert.Equal(t, id, after[path], path)
+	}
+	assert.Equal(t, uint(125), after["Battery/Module 1/NTC-26"])
+	assert.Equal(t, uint(200), after["Battery/Module 2/NTC-1"])
+}
+
+func TestNewConfigFromReader_TemplateErrors(t *testing.T) {
+	_, err := NewConfigFromReader(strings.NewReader(`
+sensors:
+  - {name: "NTC-{1..3}", id: 1, section: Battery, module: M1}
+  - {name: "NTC-{5..1}", id: 10, section: Battery, module: M2}
+  - {name: Pump, id: 3, section: Cooling, module: M1}
+`))
+
+	assert.Equal(t, ValidationErrors{
+		{Path: "sensors[1]", Message: `name: invalid group in "NTC-{5..1}": invalid range end "1"`},
+	}, err)
+
+	_, err = NewConfigFromReader(strings.NewReader(`
+sensors:
+  - {name: "NTC-{1..3}", id: 1, section: Battery, module: M1}
+  - {name: Pump, id: 3, section: Cooling, module: M1}
+`))
+
+	assert.Equal(t, ValidationErrors{
+		{Path: "sensors[1].id", Message: "duplicate id 3, already used by sensors[0]"},
+	}, err)
+
+	_, err = NewConfigFromReader(strings.NewReader(`
+sensors:
+  - {name: "NTC-{1..3}", id: 1, section: Battery, module: "M{1..2}"}
+  - {name: "NTC-{1..3}", id: 10, section: Cooling, module: "M{1..2}", id_stride: [2, 1]}
+`))
+
+	assert.Equal(t, ValidationErrors{
+		{Path: "sensors[0]", Message: "id_stride must list one stride per group (2), in section, module, name order"},
+		{Path: "sensors[1]", Message: "id_stride [2 1] gives Cooling/M1/NTC-3 and Cooling/M2/NTC-1 the same id 12"},
+	}, err)
+
+	_, err = NewConfigFromReader(strings.NewReader(`
+sensors:
+  - {name: "Pump", id: 20, section: Cooling, module: M1, id_stride: [1]}
+`))
+
+	assert.Equal(t, ValidationErrors{
+		{Path: "sensors[0].id_stride", Message: "only allowed on templated sensors"},
+	}, err)
+}
@@ -0,0 +1,410 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/ApexCorse/ephoros/server/internal/password"
+)
+
+type ValidationError struct {
+	File    string `json:"file,omitempty"`
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (e ValidationError) Error() string {
+	message := e.Message
+	if e.Path != "" {
+		message = e.Path + ": " + message
+	}
+
+	if e.File != "" {
+		message = e.File + ": " + message
+	}
+
+	return message
+}
+
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	if len(e) == 1 {
+		return "invalid configuration: " + e[0].Error()
+	}
+
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "invalid configuration, %d problems:", len(e))
+	for _, err := range e {
+		b.WriteString("\n  ")
+		b.WriteString(err.Error())
+	}
+
+	return b.String()
+}
+
+func (e ValidationErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+
+	return errs
+}
+
+func (c *Config) Validate() error {
+	errs := ValidationErrors{}
+
+	sensorIDs := make(map[uint]origin)
+	sensorPaths := make(map[string]origin)
+	for i := range c.SensorConfigs {
+		sConfig := &c.SensorConfigs[i]
+		o := c.sensorOrigin(i)
+		path := o.path("sensors")
+
+		errs = append(errs, withFile(o.file, sConfig.validate(path))...)
+
+		if sConfig.ID != 0 {
+			if first, ok := sensorIDs[sConfig.ID]; ok {
+				errs = append(errs, ValidationError{
+					File:    o.file,
+					Path:    path + ".id",
+					Message: fmt.Sprintf("duplicate id %d, already used by %s", sConfig.ID, first.ref("sensors", o)),
+				})
+			} else {
+				sensorIDs[sConfig.ID] = o
+			}
+		}
+
+		if sConfig.Name != "" && sConfig.Section != "" && sConfig.Module != "" {
+			if first, ok := sensorPaths[sConfig.Path()]; ok {
+				errs = append(errs, ValidationError{
+					File:    o.file,
+					Path:    path,
+					Message: fmt.Sprintf("duplicate sensor %s, already defined by %s", sConfig.Path(), first.ref("sensors", o)),
+				})
+			} else {
+				sensorPaths[sConfig.Path()] = o
+			}
+		}
+	}
+
+	if c.Broker != nil {
+		errs = append(errs, c.Broker.validate("broker")...)
+	}
+
+	usernames := make(map[string]origin)
+	for i := range c.MQTT {
+		mConfig := &c.MQTT[i]
+		o := c.mqttOrigin(i)
+		path := o.path("mqtt")
+
+		errs = append(errs, withFile(o.file, mConfig.validate(path))...)
+
+		if mConfig.Username == "" {
+			continue
+		}
+
+		if first, ok := usernames[mConfig.Username]; ok {
+			errs = append(errs, ValidationError{
+				File:    o.file,
+				Path:    path + ".username",
+				Message: fmt.Sprintf("duplicate username %q, already used by %s", mConfig.Username, first.ref("mqtt", o)),
+			})
+		} else {
+			usernames[mConfig.Username] = o
+		}
+	}
+
+	alertNames := make(map[string]origin)
+	for i := range c.Alerts {
+		aConfig := &c.Alerts[i]
+		o := c.alertOrigin(i)
+		path := o.path("alerts")
+
+		errs = append(errs, withFile(o.file, aConfig.validate(path))...)
+
+		if aConfig.Name != "" {
+			if first, ok := alertNames[aConfig.Name]; ok {
+				errs = append(errs, ValidationError{
+					File:    o.file,
+					Path:    path + ".name",
+					Message: fmt.Sprintf("duplicate alert %q, already defined by %s", aConfig.Name, first.ref("alerts", o)),
+				})
+			} else {
+				alertNames[aConfig.Name] = o
+			}
+		}
+
+		if aConfig.Section != "" && aConfig.Module != "" && !c.alertMatchesSensor(aConfig) {
+			errs = append(errs, ValidationError{
+				File:    o.file,
+				Path:    path,
+				Message: "matches no configured sensor",
+			})
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+func (c *Config) alertMatchesSensor(aConfig *AlertRuleConfig) bool {
+	for i := range c.SensorConfigs {
+		sConfig := &c.SensorConfigs[i]
+		if aConfig.Matches(sConfig.Section, sConfig.Module, sConfig.Name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *SensorConfig) validate(path string) []ValidationError {
+	errs := make([]ValidationError, 0)
+
+	errs = append(errs, validateTopicLevel(path+".name", c.Name)...)
+	errs = append(errs, validateTopicLevel(path+".section", c.Section)...)
+	errs = append(errs, validateTopicLevel(path+".module", c.Module)...)
+
+	if c.Section == ReservedSection {
+		errs = append(errs, ValidationError{
+			Path:    path + ".section",
+			Message: fmt.Sprintf("%q is reserved for server topics", ReservedSection),
+		})
+	}
+
+	if valueType, err := c.ValueType(); err != nil {
+		errs = append(errs, ValidationError{
+			Path:    path + ".encoding",
+			Message: fmt.Sprintf("unknown encoding %q", c.Encoding),
+		})
+	} else if !valueType.Lossless() {
+		errs = append(errs, ValidationError{
+			Path:    path + ".encoding",
+			Message: fmt.Sprintf("encoding %s is not supported, values above 2^53 cannot be stored exactly", valueType),
+		})
+	}
+
+	if len(c.IDStride) > 0 {
+		errs = append(errs, ValidationError{
+			Path:    path + ".id_stride",
+			Message: "only allowed on templated sensors",
+		})
+	}
+
+	if c.Min != nil && c.Max != nil && *c.Min > *c.Max {
+		errs = append(errs, ValidationError{
+			Path:    path + ".min",
+			Message: fmt.Sprintf("greater than max (%g > %g)", *c.Min, *c.Max),
+		})
+	}
+
+	return errs
+}
+
+func (c *MQTTUserConfig) validate(path string) []ValidationError {
+	errs := make([]ValidationError, 0)
+
+	if c.Username == "" {
+		errs = append(errs, ValidationError{Path: path + ".username", Message: "empty"})
+	}
+
+	if c.Password == "" {
+		errs = append(errs, ValidationError{Path: path + ".password", Message: "empty"})
+	} else if password.IsHash(c.Password) {
+		if _, err := password.Parse(c.Password); err != nil {
+			errs = append(errs, ValidationError{Path: path + ".password", Message: err.Error()})
+		}
+	}
+
+	for i, filter := range c.Publish {
+		if err := validateTopicFilter(filter); err != nil {
+			errs = append(errs, ValidationError{Path: fmt.Sprintf("%s.publish[%d]", path, i), Message: err.Error()})
+		}
+	}
+
+	for i, filter := range c.Subscribe {
+		if err := validateTopicFilter(filter); err != nil {
+			errs = append(errs, ValidationError{Path: fmt.Sprintf("%s.subscribe[%d]", path, i), Message: err.Error()})
+		}
+	}
+
+	return errs
+}
+
+func validateTopicFilter(filter string) error {
+	if filter == "" {
+		return fmt.Errorf("empty topic filter")
+	}
+
+	levels := strings.Split(filter, "/")
+	for i, level := range levels {
+		if strings.Contains(level, "#") && (level != "#" || i != len(levels)-1) {
+			return fmt.Errorf("invalid topic filter %q: '#' must be the whole last level", filter)
+		}
+
+		if strings.Contains(level, "+") && level != "+" {
+			return fmt.Errorf("invalid topic filter %q: '+' must be a whole level", filter)
+		}
+	}
+
+	return nil
+}
+
+func withFile(file string, errs []ValidationError) []ValidationError {
+	for i := range errs {
+		errs[i].File = file
+	}
+
+	return errs
+}
+
+func validateTopicLevel(path, value string) []ValidationError {
+	if value == "" {
+		return []ValidationError{{Path: path, Message: "empty"}}
+	}
+
+	if strings.ContainsAny(value, "/+#") {
+		return []ValidationError{{Path: path, Message: fmt.Sprintf("%q must not contain '/', '+' or '#'", value)}}
+	}
+
+	return nil
+}
+
+func checkShape(path string, value any, t reflect.Type) []ValidationError {
+	for t.Kind() == reflect.Pointer {
+		if value == nil {
+			return nil
+		}
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		object, ok := value.(map[string]any)
+		if !ok {
+			return []ValidationError{shapeError(path, "an object", value)}
+		}
+
+		fields := jsonFields(t)
+		errs := make([]ValidationError, 0)
+		for _, key := range sortedKeys(object) {
+			fieldPath := joinPath(path, key)
+
+			field, ok := fields[key]
+			if !ok {
+				errs = append(errs, ValidationError{Path: fieldPath, Message: "unknown field"})
+				continue
+			}
+
+			errs = append(errs, checkShape(fieldPath, object[key], field.Type)...)
+		}
+
+		return errs
+	case reflect.Slice:
+		if value == nil {
+			return nil
+		}
+
+		array, ok := value.([]any)
+		if !ok {
+			return []ValidationError{shapeError(path, "an array", value)}
+		}
+
+		errs := make([]ValidationError, 0)
+		for i, item := range array {
+			errs = append(errs, checkShape(fmt.Sprintf("%s[%d]", path, i), item, t.Elem())...)
+		}
+
+		return errs
+	case reflect.String:
+		if _, ok := value.(string); !ok {
+			return []ValidationError{shapeError(path, "a string", value)}
+		}
+	case reflect.Bool:
+		if _, ok := value.(bool); !ok {
+			return []ValidationError{shapeError(path, "a boolean", value)}
+		}
+	case reflect.Float32, reflect.Float64:
+		if _, ok := value.(float64); !ok {
+			return []ValidationError{shapeError(path, "a number", value)}
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, ok := value.(float64)
+		if !ok || n != math.Trunc(n) {
+			return []ValidationError{shapeError(path, "an integer", value)}
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, ok := value.(float64)
+		if !ok || n != math.Trunc(n) || n < 0 {
+			return []ValidationError{shapeError(path, "a non-negative integer", value)}
+		}
+	}
+
+	return nil
+}
+
+func jsonFields(t reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		fields[name] = field
+	}
+
+	return fields
+}
+
+func shapeError(path, expected string, value any) ValidationError {
+	got := "null"
+	switch value.(type) {
+	case map[string]any:
+		got = "object"
+	case []any:
+		got = "array"
+	case string:
+		got = "string"
+	case bool:
+		got = "boolean"
+	case float64:
+		got = "number"
+	}
+
+	return ValidationError{Path: path, Message: fmt.Sprintf("expected %s, got %s", expected, got)}
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+
+	return path + "." + key
+}
+
+func sortedKeys(object map[string]any) []string {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	return keys
+}
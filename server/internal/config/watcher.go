@@ -0,0 +1,147 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+type WatcherConfig struct {
+	Path     string
+	Interval time.Duration
+	Config   *Config
+	OnChange func(cfg *Config) error
+}
+
+type Watcher struct {
+	path     string
+	interval time.Duration
+	onChange func(cfg *Config) error
+
+	mu       sync.Mutex
+	sources  []string
+	checksum []byte
+}
+
+func NewWatcher(cfg *WatcherConfig) *Watcher {
+	if cfg.OnChange == nil {
+		log.Println("[CONFIG] Watcher has no change handler, caution")
+	}
+
+	w := &Watcher{
+		path:     cfg.Path,
+		interval: cfg.Interval,
+		onChange: cfg.OnChange,
+		sources:  []string{cfg.Path},
+	}
+
+	if cfg.Config != nil && len(cfg.Config.Sources()) > 0 {
+		w.sources = cfg.Config.Sources()
+	}
+
+	w.checksum = sourcesChecksum(w.sources)
+
+	return w
+}
+
+func (w *Watcher) Run(ctx context.Context) {
+	if w.interval <= 0 {
+		log.Println("[CONFIG] Watcher polling disabled")
+		return
+	}
+
+	log.Printf("[CONFIG] Watching %s every %s", w.path, w.interval)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("[CONFIG] Watcher stopped")
+			return
+		case <-ticker.C:
+			w.check()
+		}
+	}
+}
+
+func (w *Watcher) Reload() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.reload(sourcesChecksum(w.sources))
+}
+
+func (w *Watcher) check() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	checksum := sourcesChecksum(w.sources)
+	if bytes.Equal(checksum, w.checksum) {
+		return
+	}
+
+	log.Printf("[CONFIG] Change detected in %s", w.path)
+	w.reload(checksum)
+}
+
+func (w *Watcher) reload(checksum []byte) error {
+	log.Printf("[CONFIG] Reloading configuration from %s", w.path)
+
+	cfg, err := NewConfigFromFile(w.path)
+	if err != nil {
+		w.checksum = checksum
+		log.Printf("[CONFIG] Reload rejected, keeping current configuration: %v", err)
+		return err
+	}
+
+	if w.onChange != nil {
+		if err := w.onChange(cfg); err != nil {
+			w.checksum = checksum
+			log.Printf("[CONFIG] Reload failed, keeping current configuration: %v", err)
+			return err
+		}
+	}
+
+	if sources := cfg.Sources(); len(sources) > 0 {
+		w.sources = sources
+		checksum = sourcesChecksum(sources)
+	}
+
+	w.checksum = checksum
+	log.Println("[CONFIG] Configuration reloaded successfully")
+	return nil
+}
+
+func sourcesChecksum(sources []string) []byte {
+	hash := sha256.New()
+
+	for i, source := range sources {
+		files := []string{source}
+		if i > 0 {
+			if matches, err := filepath.Glob(source); err == nil && len(matches) > 0 {
+				files = matches
+			}
+		}
+
+		for _, file := range files {
+			hash.Write([]byte(file))
+
+			content, err := os.ReadFile(file)
+			if err != nil {
+				hash.Write([]byte{0})
+				continue
+			}
+
+			hash.Write(content)
+		}
+	}
+
+	return hash.Sum(nil)
+}
@@ -0,0 +1,145 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const watcherConfigV1 = `{
+	"sensors": [
+		{"name": "NTC-1", "id": 1, "section": "Battery", "module": "Module 1", "type": 0}
+	],
+	"mqtt": [
+		{"username": "car", "password": "secret"}
+	]
+}`
+
+const watcherConfigV2 = `{
+	"sensors": [
+		{"name": "NTC-1", "id": 1, "section": "Battery", "module": "Module 1", "type": 0},
+		{"name": "NTC-2", "id": 2, "section": "Battery", "module": "Module 1", "type": 0}
+	],
+	"mqtt": [
+		{"username": "car", "password": "secret"}
+	]
+}`
+
+func writeWatcherConfig(t *testing.T, path, content string) {
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal("cannot write config file")
+	}
+}
+
+func TestWatcher_Reload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	writeWatcherConfig(t, path, watcherConfigV1)
+
+	var applied *Config
+	w := NewWatcher(&WatcherConfig{
+		Path: path,
+		OnChange: func(cfg *Config) error {
+			applied = cfg
+			return nil
+		},
+	})
+
+	writeWatcherConfig(t, path, watcherConfigV2)
+
+	err := w.Reload()
+	assert.NoError(t, err)
+	assert.NotNil(t, applied)
+	assert.Len(t, applied.SensorConfigs, 2)
+}
+
+func TestWatcher_ReloadInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	writeWatcherConfig(t, path, watcherConfigV1)
+
+	calls := 0
+	w := NewWatcher(&WatcherConfig{
+		Path: path,
+		OnChange: func(cfg *Config) error {
+			calls++
+			return nil
+		},
+	})
+
+	writeWatcherConfig(t, path, `{"sensors": [{"name": "", "id": 1}]}`)
+	assert.Error(t, w.Reload())
+
+	writeWatcherConfig(t, path, `{"sensors": [`)
+	assert.Error(t, w.Reload())
+
+	assert.Equal(t, 0, calls)
+}
+
+func TestWatcher_ReloadHandlerError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	writeWatcherConfig(t, path, watcherConfigV1)
+
+	w := NewWatcher(&WatcherConfig{
+		Path: path,
+		OnChange: func(cfg *Config) error {
+			return errors.New("sync failed")
+		},
+	})
+
+	assert.EqualError(t, w.Reload(), "sync failed")
+}
+
+func TestWatcher_Run(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	writeWatcherConfig(t, path, watcherConfigV1)
+
+	mu := sync.Mutex{}
+	calls := 0
+	sensors := 0
+	w := NewWatcher(&WatcherConfig{
+		Path:     path,
+		Interval: 10 * time.Millisecond,
+		OnChange: func(cfg *Config) error {
+			mu.Lock()
+			defer mu.Unlock()
+			calls++
+			sensors = len(cfg.SensorConfigs)
+			return nil
+		},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		w.Run(ctx)
+		close(done)
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	mu.Lock()
+	assert.Equal(t, 0, calls, "unchanged file must not trigger a reload")
+	mu.Unlock()
+
+	writeWatcherConfig(t, path, watcherConfigV2)
+
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return calls == 1 && sensors == 2
+	}, time.Second, 10*time.Millisecond)
+
+	time.Sleep(50 * time.Millisecond)
+	mu.Lock()
+	assert.Equal(t, 1, calls, "a change must be applied only once")
+	mu.Unlock()
+
+	cancel()
+	<-done
+}
@@ -2,31 +2,89 @@ package db
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
 )
 
 type DB struct {
-	db *gorm.DB
+	db      *gorm.DB
+	storage *StoragePolicyConfig
 }
 
 func NewDB(db *gorm.DB) *DB {
 	return &DB{db: db}
 }
 
+func AutoMigrate(db *gorm.DB) error {
+	if err := db.AutoMigrate(&Section{}, &Module{}, &Sensor{}, &Record{}, &User{}, &Alert{}); err != nil {
+		return err
+	}
+
+	if err := migrateRecordsHypertable(db); err != nil {
+		return err
+	}
+
+	return migrateRollups(db)
+}
+
+func (d *DB) SetStoragePolicy(cfg *StoragePolicyConfig) {
+	d.storage = cfg
+}
+
+func (d *DB) Transaction(fn func(tx *DB) error) error {
+	return d.db.Transaction(func(tx *gorm.DB) error {
+		return fn(&DB{db: tx, storage: d.storage})
+	})
+}
+
 func (d *DB) InsertRecord(record *Record) error {
 	tx := d.db.Create(record)
 
 	return tx.Error
 }
 
+func (d *DB) InsertRecords(records []Record, batchSize int) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	tx := d.db.CreateInBatches(records, batchSize)
+
+	return tx.Error
+}
+
 func (d *DB) InsertSensor(sensor *Sensor) error {
 	tx := d.db.Create(sensor)
 
 	return tx.Error
 }
 
+func (d *DB) UpdateSensor(sensor *Sensor) error {
+	tx := d.db.Model(sensor).Select(
+		"Name", "ModuleID", "ConfigID", "Type", "Unit", "Description",
+		"Min", "Max", "Gain", "Offset", "States", "ArchivedAt",
+	).Updates(sensor)
+
+	return tx.Error
+}
+
+func (d *DB) ArchiveSensor(sensorID uint, at time.Time) error {
+	tx := d.db.Model(&Sensor{}).Where("id = ?", sensorID).Update("archived_at", at)
+
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	if tx.RowsAffected == 0 {
+		return errors.New("sensor not found")
+	}
+
+	return nil
+}
+
 func (d *DB) InsertModule(module *Module) error {
 	tx := d.db.Create(module)
 
@@ -45,6 +103,27 @@ func (d *DB) InsertUser(user *User) error {
 	return tx.Error
 }
 
+func (d *DB) GetSections() ([]Section, error) {
+	sections := make([]Section, 0)
+	tx := d.db.Order("id").Find(&sections)
+
+	return sections, tx.Error
+}
+
+func (d *DB) GetModules() ([]Module, error) {
+	modules := make([]Module, 0)
+	tx := d.db.Order("id").Find(&modules)
+
+	return modules, tx.Error
+}
+
+func (d *DB) GetSensors() ([]Sensor, error) {
+	sensors := make([]Sensor, 0)
+	tx := d.db.Order("id").Find(&sensors)
+
+	return sensors, tx.Error
+}
+
 func (d *DB) GetModuleById(id uint) (*Module, error) {
 	module := &Module{}
 	tx := d.db.Preload("Sensors").First(module, id)
@@ -171,6 +250,7 @@ func (d *DB) GetSensorByNameAndModuleAndSection(sensorName, moduleName, sectionN
 		Where("sections.name = ?", sectionName).
 		Where("modules.name = ?", moduleName).
 		Where("sensors.name = ?", sensorName).
+		Where("sensors.archived_at IS NULL").
 		Preload("Records", d.db.Where(timeCondition, params...)).
 		First(sensor)
 
@@ -185,6 +265,100 @@ func (d *DB) GetSensorByNameAndModuleAndSection(sensorName, moduleName, sectionN
 	return sensor, nil
 }
 
+func (d *DB) FindSensorByNameAndModuleAndSection(sensorName, moduleName, sectionName string) (*Sensor, error) {
+	sensor := &Sensor{}
+
+	tx := d.db.
+		Joins("JOIN modules ON modules.id = sensors.module_id").
+		Joins("JOIN sections ON sections.id = modules.section_id").
+		Where("sections.name = ?", sectionName).
+		Where("modules.name = ?", moduleName).
+		Where("sensors.name = ?", sensorName).
+		Where("sensors.archived_at IS NULL").
+		First(sensor)
+
+	if tx.RowsAffected == 0 {
+		return nil, errors.New("sensor not found")
+	}
+
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	return sensor, nil
+}
+
+func (d *DB) GetSensorIDByNameAndModuleAndSection(sensorName, moduleName, sectionName string) (uint, error) {
+	sensor := &Sensor{}
+
+	tx := d.db.
+		Select("sensors.id").
+		Joins("JOIN modules ON modules.id = sensors.module_id").
+		Joins("JOIN sections ON sections.id = modules.section_id").
+		Where("sections.name = ?", sectionName).
+		Where("modules.name = ?", moduleName).
+		Where("sensors.name = ?", sensorName).
+		Where("sensors.archived_at IS NULL").
+		First(sensor)
+
+	if tx.RowsAffected == 0 {
+		return 0, errors.New("sensor not found")
+	}
+
+	if tx.Error != nil {
+		return 0, tx.Error
+	}
+
+	return sensor.ID, nil
+}
+
+func (d *DB) GetSensorPaths() ([]SensorPath, error) {
+	paths := make([]SensorPath, 0)
+
+	tx := d.db.
+		Model(&Sensor{}).
+		Select("sensors.id AS sensor_id, sections.name AS section, modules.name AS module, sensors.name AS sensor").
+		Joins("JOIN modules ON modules.id = sensors.module_id").
+		Joins("JOIN sections ON sections.id = modules.section_id").
+		Where("sensors.archived_at IS NULL").
+		Scan(&paths)
+
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	return paths, nil
+}
+
+func (d *DB) GetLatestRecords() ([]LatestRecord, error) {
+	latest := make([]LatestRecord, 0)
+
+	// One index lookup per sensor instead of a scan over every chunk.
+	tx := d.db.Raw(`
+		SELECT
+			sensors.id AS sensor_id, sections.name AS section, modules.name AS module, sensors.name AS sensor,
+			latest.value, latest.type, latest.created_at
+		FROM sensors
+		JOIN modules ON modules.id = sensors.module_id
+		JOIN sections ON sections.id = modules.section_id
+		CROSS JOIN LATERAL (
+			SELECT records.value, records.type, records.created_at
+			FROM records
+			WHERE records.sensor_id = sensors.id
+			ORDER BY records.created_at DESC
+			LIMIT 1
+		) latest
+		WHERE sensors.archived_at IS NULL
+		ORDER BY sensors.id
+	`).Scan(&latest)
+
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	return latest, nil
+}
+
 func (d *DB) GetUserByToken(token string) (*User, error) {
 	user := &User{}
 	tx := d.db.Where("token = ?", token).First(user)
@@ -199,3 +373,98 @@ func (d *DB) GetUserByToken(token string) (*User, error) {
 
 	return user, nil
 }
+
+func (d *DB) InsertAlert(alert *Alert) error {
+	tx := d.db.Create(alert)
+
+	return tx.Error
+}
+
+func (d *DB) ResolveAlert(alertID uint, at time.Time) error {
+	tx := d.db.
+		Model(&Alert{}).
+		Where("id = ? AND resolved_at IS NULL", alertID).
+		Update("resolved_at", at)
+
+	return tx.Error
+}
+
+func (d *DB) GetActiveAlerts() ([]Alert, error) {
+	alerts := make([]Alert, 0)
+	tx := d.db.
+		Where("resolved_at IS NULL").
+		Order("fired_at").
+		Find(&alerts)
+
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	return alerts, nil
+}
+
+func (d *DB) GetAlerts(activeOnly bool, limit int) ([]Alert, error) {
+	alerts := make([]Alert, 0)
+
+	query := d.db.Order("fired_at DESC").Limit(limit)
+	if activeOnly {
+		query = query.Where("resolved_at IS NULL")
+	}
+
+	tx := query.Find(&alerts)
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	return alerts, nil
+}
+
+func (d *DB) GetRecordBuckets(sensorID uint, from, to time.Time, bucket time.Duration, aggregates []Aggregate) ([]RecordBucket, error) {
+	if bucket <= 0 {
+		return nil, errors.New("bucket size must be positive")
+	}
+
+	if len(aggregates) == 0 {
+		return nil, errors.New("no aggregates requested")
+	}
+
+	storage := d.storage
+	if storage == nil {
+		storage = &StoragePolicyConfig{}
+	}
+
+	// Edge buckets are always read whole, so any range lines up with the
+	// rollups and both sources return the same buckets.
+	from, to = bucketRange(from, to, bucket)
+	source := sourceForRange(storage, time.Now(), from, to, bucket)
+
+	columns := make([]string, 0, len(aggregates))
+	for _, aggregate := range aggregates {
+		column, ok := source.columns[aggregate]
+		if !ok {
+			return nil, fmt.Errorf("unknown aggregate %q", aggregate)
+		}
+		columns = append(columns, column)
+	}
+
+	buckets := make([]RecordBucket, 0)
+
+	query := fmt.Sprintf(`
+		SELECT time_bucket(CAST(? AS interval), %[1]s) AS time, %[2]s
+		FROM %[3]s
+		WHERE sensor_id = ? AND %[1]s >= ? AND %[1]s < ?
+		GROUP BY 1
+		ORDER BY 1
+	`, source.timeColumn, strings.Join(columns, ", "), source.table)
+
+	tx := d.db.Raw(query, intervalLiteral(bucket), sensorID, from, to).Scan(&buckets)
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	return buckets, nil
+}
+
+func intervalLiteral(d time.Duration) string {
+	return fmt.Sprintf("%d microseconds", d.Microseconds())
+}
@@ -134,6 +134,57 @@ func TestInsertRecord(t *testing.T) {
 	assert.Equal(t, record.Value, dbRecord.Value)
 }
 
+func TestInsertRecords(t *testing.T) {
+	gormDb, cleanUp, err := TestDB()
+	if err != nil {
+		t.Fatal("cannot setup db")
+	}
+	defer cleanUp()
+
+	db := NewDB(gormDb)
+
+	section := &Section{
+		Name: "Trial",
+	}
+	gormDb.Create(section)
+
+	module := &Module{
+		Name:      "Trial",
+		SectionID: section.ID,
+	}
+	gormDb.Create(module)
+
+	sensor := &Sensor{
+		Name:     "Trial",
+		ModuleID: module.ID,
+	}
+	gormDb.Create(sensor)
+
+	records := []Record{
+		{
+			Value:    42,
+			SensorID: sensor.ID,
+		},
+		{
+			Value:    43,
+			SensorID: sensor.ID,
+		},
+		{
+			Value:    44,
+			SensorID: sensor.ID,
+		},
+	}
+	err = db.InsertRecords(records, 2)
+	assert.Nil(t, err)
+
+	var count int64
+	gormDb.Model(&Record{}).Where("sensor_id = ?", sensor.ID).Count(&count)
+
+	assert.Equal(t, int64(3), count)
+	assert.NotZero(t, records[0].ID)
+	assert.NotZero(t, records[2].ID)
+}
+
 func TestInsertUser(t *testing.T) {
 	gormDb, cleanUp, err := TestDB()
 	if err != nil {
@@ -451,9 +502,9 @@ func TestGetSensorByNameAndModuleAndSection_Success(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, sensor.Name, dbSensor.Name)
 	assert.Len(t, dbSensor.Records, 3)
-	assert.Equal(t, float32(42), dbSensor.Records[0].Value)
-	assert.Equal(t, float32(43), dbSensor.Records[1].Value)
-	assert.Equal(t, float32(44), dbSensor.Records[2].Value)
+	assert.Equal(t, float64(42), dbSensor.Records[0].Value)
+	assert.Equal(t, float64(43), dbSensor.Records[1].Value)
+	assert.Equal(t, float64(44), dbSensor.Records[2].Value)
 }
 
 func TestGetSensorByNameAndModuleAndSection_Failure(t *testing.T) {
@@ -525,3 +576,161 @@ func TestGetUserByToken_Success(t *testing.T) {
 	assert.Equal(t, user.Username, dbUser.Username)
 	assert.Equal(t, user.Token, dbUser.Token)
 }
+
+func TestAlerts(t *testing.T) {
+	gormDb, cleanUp, err := TestDB()
+	if err != nil {
+		t.Fatal("cannot setup db")
+	}
+	defer cleanUp()
+
+	db := NewDB(gormDb)
+
+	firedAt := time.Now().Add(-time.Minute).UTC().Truncate(time.Millisecond)
+	overtemp := &Alert{
+		Rule:      "cell-overtemp",
+		Kind:      "max",
+		Severity:  "critical",
+		Section:   "Battery",
+		Module:    "Module 1",
+		Sensor:    "NTC-1",
+		Value:     61,
+		Threshold: 60,
+		FiredAt:   firedAt,
+	}
+	assert.Nil(t, db.InsertAlert(overtemp))
+
+	undervoltage := &Alert{
+		Rule:     "pack-undervoltage",
+		Kind:     "min",
+		Severity: "warning",
+		Section:  "Battery",
+		Module:   "Pack",
+		Sensor:   "Voltage",
+		FiredAt:  firedAt.Add(time.Second),
+	}
+	assert.Nil(t, db.InsertAlert(undervoltage))
+
+	active, err := db.GetActiveAlerts()
+	assert.Nil(t, err)
+	assert.Len(t, active, 2)
+	assert.Equal(t, "cell-overtemp", active[0].Rule)
+
+	assert.Nil(t, db.ResolveAlert(overtemp.ID, firedAt.Add(time.Minute)))
+
+	active, err = db.GetActiveAlerts()
+	assert.Nil(t, err)
+	assert.Len(t, active, 1)
+	assert.Equal(t, "pack-undervoltage", active[0].Rule)
+
+	alerts, err := db.GetAlerts(false, 10)
+	assert.Nil(t, err)
+	assert.Len(t, alerts, 2)
+	assert.Equal(t, "pack-undervoltage", alerts[0].Rule)
+	assert.False(t, alerts[1].Active())
+
+	alerts, err = db.GetAlerts(true, 10)
+	assert.Nil(t, err)
+	assert.Len(t, alerts, 1)
+
+	alerts, err = db.GetAlerts(false, 1)
+	assert.Nil(t, err)
+	assert.Len(t, alerts, 1)
+}
+
+func TestGetLatestRecords(t *testing.T) {
+	gormDb, cleanUp, err := TestDB()
+	if err != nil {
+		t.Fatal("cannot setup db")
+	}
+	defer cleanUp()
+
+	db := NewDB(gormDb)
+
+	section := &Section{Name: "Battery"}
+	gormDb.Create(section)
+	module := &Module{Name: "Pack", SectionID: section.ID}
+	gormDb.Create(module)
+
+	current := &Sensor{Name: "Current", ModuleID: module.ID}
+	voltage := &Sensor{Name: "Voltage", ModuleID: module.ID, Type: TypeInt16}
+	archivedAt := time.Now()
+	archived := &Sensor{Name: "Old", ModuleID: module.ID, ArchivedAt: &archivedAt}
+	silent := &Sensor{Name: "Silent", ModuleID: module.ID}
+	gormDb.Create([]*Sensor{current, voltage, archived, silent})
+
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	gormDb.Create([]*Record{
+		{SensorID: current.ID, Value: 1, CreatedAt: now.Add(-2 * time.Second)},
+		{SensorID: current.ID, Value: 3, CreatedAt: now},
+		{SensorID: current.ID, Value: 2, CreatedAt: now.Add(-time.Second)},
+		{SensorID: voltage.ID, Value: 400, Type: TypeInt16, CreatedAt: now.Add(-time.Minute)},
+		{SensorID: archived.ID, Value: 9, CreatedAt: now},
+	})
+
+	latest, err := db.GetLatestRecords()
+	assert.Nil(t, err)
+	assert.Len(t, latest, 2)
+
+	assert.Equal(t, current.ID, latest[0].SensorID)
+	assert.Equal(t, "Battery", latest[0].Section)
+	assert.Equal(t, "Pack", latest[0].Module)
+	assert.Equal(t, "Current", latest[0].Sensor)
+	assert.Equal(t, 3.0, latest[0].Value)
+	assert.True(t, now.Equal(latest[0].CreatedAt))
+
+	assert.Equal(t, "Voltage", latest[1].Sensor)
+	assert.Equal(t, TypeInt16, latest[1].Record().Type)
+}
+
+func TestGetRecordBuckets(t *testing.T) {
+	gormDb, cleanUp, err := TestDB()
+	if err != nil {
+		t.Fatal("cannot setup db")
+	}
+	defer cleanUp()
+
+	db := NewDB(gormDb)
+
+	section := &Section{Name: "Battery"}
+	gormDb.Create(section)
+	module := &Module{Name: "Pack", SectionID: section.ID}
+	gormDb.Create(module)
+	sensor := &Sensor{Name: "Current", ModuleID: module.ID}
+	gormDb.Create(sensor)
+
+	start := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	gormDb.Create([]*Record{
+		{SensorID: sensor.ID, Value: 1, CreatedAt: start},
+		{SensorID: sensor.ID, Value: 3, CreatedAt: start.Add(20 * time.Second)},
+		{SensorID: sensor.ID, Value: 2, CreatedAt: start.Add(40 * time.Second)},
+		{SensorID: sensor.ID, Value: 10, CreatedAt: start.Add(70 * time.Second)},
+		{SensorID: sensor.ID, Value: 99, CreatedAt: start.Add(3 * time.Minute)},
+	})
+
+	buckets, err := db.GetRecordBuckets(sensor.ID, start, start.Add(2*time.Minute), time.Minute,
+		[]Aggregate{AggregateMin, AggregateMax, AggregateAvg, AggregateLast, AggregateCount})
+	assert.Nil(t, err)
+	assert.Len(t, buckets, 2)
+
+	assert.True(t, start.Equal(buckets[0].Time))
+	assert.Equal(t, 1.0, *buckets[0].Min)
+	assert.Equal(t, 3.0, *buckets[0].Max)
+	assert.Equal(t, 2.0, *buckets[0].Avg)
+	assert.Equal(t, 2.0, *buckets[0].Last)
+	assert.Equal(t, int64(3), *buckets[0].Count)
+
+	assert.True(t, start.Add(time.Minute).Equal(buckets[1].Time))
+	assert.Equal(t, 10.0, *buckets[1].Last)
+	assert.Equal(t, int64(1), *buckets[1].Count)
+
+	buckets, err = db.GetRecordBuckets(sensor.ID, start, start.Add(2*time.Minute), time.Minute, []Aggregate{AggregateAvg})
+	assert.Nil(t, err)
+	assert.Nil(t, buckets[0].Min)
+	assert.NotNil(t, buckets[0].Avg)
+
+	_, err = db.GetRecordBuckets(sensor.ID, start, start.Add(time.Minute), 0, []Aggregate{AggregateAvg})
+	assert.NotNil(t, err)
+	_, err = db.GetRecordBuckets(sensor.ID, start, start.Add(time.Minute), time.Minute, nil)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,138 @@
+package db
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type recordSource struct {
+	table      string
+	timeColumn string
+	resolution time.Duration
+	columns    map[Aggregate]string
+}
+
+type rollup struct {
+	recordSource
+	startOffset time.Duration
+	endOffset   time.Duration
+	schedule    time.Duration
+}
+
+var rollupColumns = map[Aggregate]string{
+	AggregateMin:   "min(value_min) AS min",
+	AggregateMax:   "max(value_max) AS max",
+	AggregateAvg:   "(sum(value_avg * value_count) / sum(value_count))::double precision AS avg",
+	AggregateLast:  "last(value_last, bucket) AS last",
+	AggregateCount: "sum(value_count)::bigint AS count",
+}
+
+var rawRecords = recordSource{
+	table:      "records",
+	timeColumn: "created_at",
+	columns:    aggregateColumns,
+}
+
+// Ordered from the coarsest to the finest resolution.
+var rollups = []rollup{
+	{
+		recordSource: recordSource{table: "records_1m", timeColumn: "bucket", resolution: time.Minute, columns: rollupColumns},
+		startOffset:  7 * 24 * time.Hour,
+		endOffset:    time.Minute,
+		schedule:     5 * time.Minute,
+	},
+	{
+		recordSource: recordSource{table: "records_1s", timeColumn: "bucket", resolution: time.Second, columns: rollupColumns},
+		startOffset:  6 * time.Hour,
+		endOffset:    time.Second,
+		schedule:     30 * time.Second,
+	},
+}
+
+// A rollup only gives the same answer as the raw records when the bucket
+// and both ends of the range fall on its bucket boundaries, and when the
+// range lies inside its refresh window: records written late for older
+// ranges never reach it.
+func sourceForRange(cfg *StoragePolicyConfig, now, from, to time.Time, bucket time.Duration) recordSource {
+	for _, r := range rollups {
+		if bucket < r.resolution || bucket%r.resolution != 0 {
+			continue
+		}
+
+		if !from.Truncate(r.resolution).Equal(from) || !to.Truncate(r.resolution).Equal(to) {
+			continue
+		}
+
+		if from.Before(now.Add(-cfg.rollupWindow(r))) {
+			continue
+		}
+
+		return r.recordSource
+	}
+
+	return rawRecords
+}
+
+// time_bucket's default origin for sub-monthly buckets.
+var bucketOrigin = time.Date(2000, 1, 3, 0, 0, 0, 0, time.UTC)
+
+func bucketStart(t time.Time, bucket time.Duration) time.Time {
+	offset := t.Sub(bucketOrigin) % bucket
+	if offset < 0 {
+		offset += bucket
+	}
+
+	return t.Add(-offset)
+}
+
+func bucketRange(from, to time.Time, bucket time.Duration) (time.Time, time.Time) {
+	end := bucketStart(to, bucket)
+	if end.Before(to) {
+		end = end.Add(bucket)
+	}
+
+	return bucketStart(from, bucket), end
+}
+
+func migrateRollups(db *gorm.DB) error {
+	for _, r := range rollups {
+		err := db.Exec(fmt.Sprintf(`
+			CREATE MATERIALIZED VIEW IF NOT EXISTS %s
+			WITH (timescaledb.continuous, timescaledb.materialized_only = false) AS
+			SELECT
+				sensor_id,
+				time_bucket(INTERVAL '%s', created_at) AS bucket,
+				min(value) AS value_min,
+				max(value) AS value_max,
+				avg(value) AS value_avg,
+				count(*) AS value_count,
+				last(value, created_at) AS value_last
+			FROM records
+			GROUP BY sensor_id, bucket
+			WITH NO DATA
+		`, r.table, intervalLiteral(r.resolution))).Error
+		if err != nil {
+			return fmt.Errorf("cannot create continuous aggregate %s: %w", r.table, err)
+		}
+
+		err = db.Exec(`
+			SELECT add_continuous_aggregate_policy(
+				CAST(? AS regclass),
+				start_offset => CAST(? AS interval),
+				end_offset => CAST(? AS interval),
+				schedule_interval => CAST(? AS interval),
+				if_not_exists => TRUE
+			)
+		`, r.table, intervalLiteral(r.startOffset), intervalLiteral(r.endOffset), intervalLiteral(r.schedule)).Error
+		if err != nil {
+			return fmt.Errorf("cannot add refresh policy for %s: %w", r.table, err)
+		}
+
+		log.Printf("[DB] Continuous aggregate %s refreshed every %s", r.table, r.schedule)
+	}
+
+	return nil
+}
@@ -0,0 +1,174 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestSourceForRange(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	from, to := now.Add(-time.Hour), now
+	cfg := &StoragePolicyConfig{}
+
+	assert.Equal(t, "records", sourceForRange(cfg, now, from, to, 500*time.Millisecond).table)
+	assert.Equal(t, "records", sourceForRange(cfg, now, from, to, 1500*time.Millisecond).table)
+	assert.Equal(t, "records_1s", sourceForRange(cfg, now, from, to, time.Second).table)
+	assert.Equal(t, "records_1s", sourceForRange(cfg, now, from, to, 30*time.Second).table)
+	assert.Equal(t, "records_1s", sourceForRange(cfg, now, from, to, 90*time.Second).table)
+	assert.Equal(t, "records_1m", sourceForRange(cfg, now, from, to, time.Minute).table)
+	assert.Equal(t, "records_1m", sourceForRange(cfg, now, from, to, 24*time.Hour).table)
+
+	assert.Equal(t, "records_1s", sourceForRange(cfg, now, from.Add(time.Second), to, time.Minute).table)
+	assert.Equal(t, "records_1s", sourceForRange(cfg, now, from, to.Add(-time.Second), time.Minute).table)
+	assert.Equal(t, "records", sourceForRange(cfg, now, from.Add(500*time.Millisecond), to, time.Minute).table)
+	assert.Equal(t, "records", sourceForRange(cfg, now, from, to.Add(time.Millisecond), time.Second).table)
+
+	old := now.Add(-2 * 24 * time.Hour)
+	assert.Equal(t, "records_1m", sourceForRange(cfg, now, old, to, time.Minute).table)
+	assert.Equal(t, "records", sourceForRange(cfg, now, old, to, 30*time.Second).table)
+	assert.Equal(t, "records", sourceForRange(cfg, now, now.Add(-8*24*time.Hour), to, time.Hour).table)
+
+	cfg = &StoragePolicyConfig{SecondRollupWindow: 3 * 24 * time.Hour}
+	assert.Equal(t, "records_1s", sourceForRange(cfg, now, old, to, 30*time.Second).table)
+}
+
+func TestBucketRange(t *testing.T) {
+	from := time.Date(2025, 6, 1, 11, 30, 12, 345000000, time.UTC)
+	to := time.Date(2025, 6, 1, 12, 0, 12, 345000000, time.UTC)
+
+	start, end := bucketRange(from, to, 30*time.Second)
+	assert.Equal(t, time.Date(2025, 6, 1, 11, 30, 0, 0, time.UTC), start)
+	assert.Equal(t, time.Date(2025, 6, 1, 12, 0, 30, 0, time.UTC), end)
+
+	start, end = bucketRange(from.Truncate(time.Minute), to.Truncate(time.Minute), time.Minute)
+	assert.Equal(t, from.Truncate(time.Minute), start)
+	assert.Equal(t, to.Truncate(time.Minute), end)
+
+	// Buckets that do not divide a day still start where time_bucket puts them.
+	start, _ = bucketRange(from, to, 7*time.Second)
+	assert.Equal(t, time.Duration(0), start.Sub(bucketOrigin)%(7*time.Second))
+	assert.False(t, start.After(from))
+	assert.True(t, start.Add(7*time.Second).After(from))
+
+	old := time.Date(1999, 12, 31, 23, 59, 59, 500000000, time.UTC)
+	start, end = bucketRange(old, old, time.Second)
+	assert.Equal(t, old.Truncate(time.Second), start)
+	assert.Equal(t, old.Truncate(time.Second).Add(time.Second), end)
+
+	// A typical chart range, unaligned on both ends, is served from a rollup.
+	now := to
+	start, end = bucketRange(now.Add(-30*time.Minute), now, 30*time.Second)
+	assert.Equal(t, "records_1s", sourceForRange(&StoragePolicyConfig{}, now, start, end, 30*time.Second).table)
+}
+
+func rawRecordBuckets(t *testing.T, gormDb *gorm.DB, sensorID uint, from, to time.Time, bucket time.Duration) []RecordBucket {
+	var buckets []RecordBucket
+	err := gormDb.Raw(`
+		SELECT time_bucket(CAST(? AS interval), created_at) AS time,
+			min(value) AS min, max(value) AS max, avg(value) AS avg,
+			last(value, created_at) AS last, count(*) AS count
+		FROM records
+		WHERE sensor_id = ? AND created_at >= ? AND created_at < ?
+		GROUP BY 1 ORDER BY 1
+	`, intervalLiteral(bucket), sensorID, from, to).Scan(&buckets).Error
+	assert.Nil(t, err)
+
+	return buckets
+}
+
+func TestRollups(t *testing.T) {
+	gormDb, cleanUp, err := TestDB()
+	if err != nil {
+		t.Fatal("cannot setup db")
+	}
+	defer cleanUp()
+
+	db := NewDB(gormDb)
+
+	var views []string
+	err = gormDb.Raw(`
+		SELECT view_name FROM timescaledb_information.continuous_aggregates
+		ORDER BY view_name
+	`).Scan(&views).Error
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"records_1m", "records_1s"}, views)
+
+	var policies int64
+	err = gormDb.Raw(`
+		SELECT count(*) FROM timescaledb_information.jobs
+		WHERE proc_name = 'policy_refresh_continuous_aggregate'
+	`).Scan(&policies).Error
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), policies)
+
+	assert.Nil(t, migrateRollups(gormDb))
+
+	section := &Section{Name: "Battery"}
+	gormDb.Create(section)
+	module := &Module{Name: "Pack", SectionID: section.ID}
+	gormDb.Create(module)
+	sensor := &Sensor{Name: "Current", ModuleID: module.ID}
+	gormDb.Create(sensor)
+
+	start := time.Now().UTC().Truncate(time.Minute).Add(-time.Hour)
+	gormDb.Create([]*Record{
+		{SensorID: sensor.ID, Value: 1, CreatedAt: start},
+		{SensorID: sensor.ID, Value: 2, CreatedAt: start.Add(100 * time.Millisecond)},
+		{SensorID: sensor.ID, Value: 6, CreatedAt: start.Add(1200 * time.Millisecond)},
+		{SensorID: sensor.ID, Value: 5, CreatedAt: start.Add(2500 * time.Millisecond)},
+	})
+
+	buckets, err := db.GetRecordBuckets(sensor.ID, start, start.Add(4*time.Second), 2*time.Second,
+		[]Aggregate{AggregateMin, AggregateMax, AggregateAvg, AggregateLast, AggregateCount})
+	assert.Nil(t, err)
+	assert.Len(t, buckets, 2)
+
+	assert.True(t, start.Equal(buckets[0].Time))
+	assert.Equal(t, 1.0, *buckets[0].Min)
+	assert.Equal(t, 6.0, *buckets[0].Max)
+	assert.Equal(t, 3.0, *buckets[0].Avg)
+	assert.Equal(t, 6.0, *buckets[0].Last)
+	assert.Equal(t, int64(3), *buckets[0].Count)
+
+	assert.Equal(t, 5.0, *buckets[1].Last)
+	assert.Equal(t, int64(1), *buckets[1].Count)
+
+	err = gormDb.Exec("CALL refresh_continuous_aggregate('records_1s', NULL, NULL)").Error
+	assert.Nil(t, err)
+
+	materialized, err := db.GetRecordBuckets(sensor.ID, start, start.Add(4*time.Second), 2*time.Second,
+		[]Aggregate{AggregateMin, AggregateMax, AggregateAvg, AggregateLast, AggregateCount})
+	assert.Nil(t, err)
+	assert.Equal(t, buckets, materialized)
+
+	unaligned, err := db.GetRecordBuckets(sensor.ID, start.Add(50*time.Millisecond), start.Add(4*time.Second), 2*time.Second,
+		[]Aggregate{AggregateMin, AggregateMax, AggregateAvg, AggregateLast, AggregateCount})
+	assert.Nil(t, err)
+	assert.Equal(t, buckets, unaligned)
+
+	unaligned, err = db.GetRecordBuckets(sensor.ID, start.Add(1500*time.Millisecond), start.Add(2500*time.Millisecond), 2*time.Second,
+		[]Aggregate{AggregateMin, AggregateMax, AggregateAvg, AggregateLast, AggregateCount})
+	assert.Nil(t, err)
+	assert.Equal(t, rawRecordBuckets(t, gormDb, sensor.ID, start, start.Add(4*time.Second), 2*time.Second), unaligned)
+
+	err = gormDb.Exec("CALL refresh_continuous_aggregate('records_1m', NULL, NULL)").Error
+	assert.Nil(t, err)
+
+	// Written after the rollups were materialized and older than their
+	// refresh window, these records only exist in the raw data.
+	late := start.Add(-8 * 24 * time.Hour)
+	gormDb.Create([]*Record{
+		{SensorID: sensor.ID, Value: 4, CreatedAt: late},
+		{SensorID: sensor.ID, Value: 8, CreatedAt: late.Add(30 * time.Second)},
+		{SensorID: sensor.ID, Value: 2, CreatedAt: late.Add(90 * time.Second)},
+	})
+
+	lateBuckets, err := db.GetRecordBuckets(sensor.ID, late, late.Add(2*time.Minute), time.Minute,
+		[]Aggregate{AggregateMin, AggregateMax, AggregateAvg, AggregateLast, AggregateCount})
+	assert.Nil(t, err)
+	assert.Len(t, lateBuckets, 2)
+	assert.Equal(t, rawRecordBuckets(t, gormDb, sensor.ID, late, late.Add(2*time.Minute), time.Minute), lateBuckets)
+}
@@ -0,0 +1,53 @@
+package db
+
+import (
+	"log"
+	"sync/atomic"
+)
+
+type SensorCache struct {
+	db      *DB
+	sensors atomic.Pointer[map[string]uint]
+}
+
+func NewSensorCache(db *DB) *SensorCache {
+	c := &SensorCache{db: db}
+
+	empty := make(map[string]uint)
+	c.sensors.Store(&empty)
+
+	return c
+}
+
+func (c *SensorCache) Refresh() error {
+	log.Println("[DB] Refreshing sensor cache")
+
+	paths, err := c.db.GetSensorPaths()
+	if err != nil {
+		log.Printf("[DB] Error refreshing sensor cache: %v", err)
+		return err
+	}
+
+	sensors := make(map[string]uint, len(paths))
+	for _, path := range paths {
+		sensors[sensorKey(path.Section, path.Module, path.Sensor)] = path.SensorID
+	}
+
+	c.sensors.Store(&sensors)
+
+	log.Printf("[DB] Sensor cache refreshed - Sensors: %d", len(sensors))
+	return nil
+}
+
+func (c *SensorCache) Lookup(sectionName, moduleName, sensorName string) (uint, bool) {
+	id, ok := (*c.sensors.Load())[sensorKey(sectionName, moduleName, sensorName)]
+	return id, ok
+}
+
+func (c *SensorCache) Len() int {
+	return len(*c.sensors.Load())
+}
+
+func sensorKey(sectionName, moduleName, sensorName string) string {
+	return sectionName + "/" + moduleName + "/" + sensorName
+}
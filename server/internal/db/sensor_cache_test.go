@@ -0,0 +1,64 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSensorCache(t *testing.T) {
+	gormDb, cleanUp, err := TestDB()
+	if err != nil {
+		t.Fatal("cannot setup db")
+	}
+	defer cleanUp()
+
+	db := NewDB(gormDb)
+
+	section := &Section{
+		Name: "Battery",
+	}
+	gormDb.Create(section)
+
+	module := &Module{
+		Name:      "Module 1",
+		SectionID: section.ID,
+	}
+	gormDb.Create(module)
+
+	sensor := &Sensor{
+		Name:     "NTC-1",
+		ModuleID: module.ID,
+	}
+	gormDb.Create(sensor)
+
+	cache := NewSensorCache(db)
+	assert.Equal(t, 0, cache.Len())
+
+	err = cache.Refresh()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, cache.Len())
+
+	id, ok := cache.Lookup("Battery", "Module 1", "NTC-1")
+	assert.True(t, ok)
+	assert.Equal(t, sensor.ID, id)
+
+	_, ok = cache.Lookup("Battery", "Module 1", "NTC-2")
+	assert.False(t, ok)
+
+	newSensor := &Sensor{
+		Name:     "NTC-2",
+		ModuleID: module.ID,
+	}
+	gormDb.Create(newSensor)
+
+	_, ok = cache.Lookup("Battery", "Module 1", "NTC-2")
+	assert.False(t, ok)
+
+	err = cache.Refresh()
+	assert.Nil(t, err)
+
+	id, ok = cache.Lookup("Battery", "Module 1", "NTC-2")
+	assert.True(t, ok)
+	assert.Equal(t, newSensor.ID, id)
+}
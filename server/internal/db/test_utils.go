@@ -30,7 +30,7 @@ func TestDB() (*gorm.DB, func(), error) {
 		return nil, nil, err
 	}
 
-	testDB.AutoMigrate(&Section{}, &Module{}, &Sensor{}, &Record{}, &User{})
+	AutoMigrate(testDB)
 
 	cleanup := func() {
 		sqlDB, _ := testDB.DB()
@@ -0,0 +1,224 @@
+package db
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type StoragePolicyConfig struct {
+	ChunkInterval time.Duration
+	CompressAfter time.Duration
+	RetainFor     time.Duration
+
+	// How far back the rollups are refreshed, records arriving later than
+	// this are only visible in the raw data. 0 keeps the default window.
+	SecondRollupWindow time.Duration
+	MinuteRollupWindow time.Duration
+}
+
+func (c *StoragePolicyConfig) rollupWindow(r rollup) time.Duration {
+	window := c.MinuteRollupWindow
+	if r.resolution == time.Second {
+		window = c.SecondRollupWindow
+	}
+
+	if window <= 0 {
+		return r.startOffset
+	}
+	return window
+}
+
+func (c *StoragePolicyConfig) Validate() error {
+	for _, r := range rollups {
+		window := c.rollupWindow(r)
+
+		if minWindow := r.endOffset + 2*r.resolution; window < minWindow {
+			return fmt.Errorf("refresh window of %s must be at least %s", r.table, minWindow)
+		}
+
+		// Refreshing a range whose records were already dropped would
+		// delete the aggregated history along with them.
+		if c.RetainFor > 0 && window >= c.RetainFor {
+			return fmt.Errorf("refresh window of %s (%s) must be shorter than the records retention (%s)",
+				r.table, window, c.RetainFor)
+		}
+	}
+
+	return nil
+}
+
+func migrateRecordsHypertable(db *gorm.DB) error {
+	if err := db.Exec("CREATE EXTENSION IF NOT EXISTS timescaledb").Error; err != nil {
+		return fmt.Errorf("cannot enable timescaledb: %w", err)
+	}
+
+	var keyColumns int64
+	err := db.Raw(`
+		SELECT count(*)
+		FROM information_schema.key_column_usage
+		WHERE table_name = 'records' AND constraint_name = 'records_pkey'
+	`).Scan(&keyColumns).Error
+	if err != nil {
+		return err
+	}
+
+	if keyColumns == 1 {
+		log.Println("[DB] Extending records primary key with created_at")
+
+		err := db.Exec(`
+			ALTER TABLE records
+			DROP CONSTRAINT records_pkey,
+			ADD PRIMARY KEY (id, created_at)
+		`).Error
+		if err != nil {
+			return fmt.Errorf("cannot change records primary key: %w", err)
+		}
+	}
+
+	isHypertable, err := recordsIsHypertable(db)
+	if err != nil {
+		return err
+	}
+
+	if !isHypertable {
+		log.Println("[DB] Converting records into a hypertable, existing rows are migrated")
+
+		err := db.Exec("SELECT create_hypertable('records', by_range('created_at'), migrate_data => TRUE)").Error
+		if err != nil {
+			return fmt.Errorf("cannot create records hypertable: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func recordsIsHypertable(db *gorm.DB) (bool, error) {
+	var exists bool
+	err := db.Raw(`
+		SELECT EXISTS (
+			SELECT 1 FROM timescaledb_information.hypertables
+			WHERE hypertable_name = 'records'
+		)
+	`).Scan(&exists).Error
+
+	return exists, err
+}
+
+func ApplyStoragePolicies(db *gorm.DB, cfg *StoragePolicyConfig) error {
+	if cfg == nil {
+		log.Println("[DB] Storage policy config is nil, caution")
+		cfg = &StoragePolicyConfig{}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	if cfg.ChunkInterval > 0 {
+		err := db.Exec("SELECT set_chunk_time_interval('records', CAST(? AS interval))", intervalLiteral(cfg.ChunkInterval)).Error
+		if err != nil {
+			return fmt.Errorf("cannot set records chunk interval: %w", err)
+		}
+		log.Printf("[DB] Records chunk interval set to %s", cfg.ChunkInterval)
+	}
+
+	if err := applyCompressionPolicy(db, cfg.CompressAfter); err != nil {
+		return err
+	}
+
+	if err := applyRetentionPolicy(db, cfg.RetainFor); err != nil {
+		return err
+	}
+
+	return applyRefreshPolicies(db, cfg)
+}
+
+func applyRefreshPolicies(db *gorm.DB, cfg *StoragePolicyConfig) error {
+	for _, r := range rollups {
+		err := db.Exec("SELECT remove_continuous_aggregate_policy(CAST(? AS regclass), if_exists => TRUE)", r.table).Error
+		if err != nil {
+			return fmt.Errorf("cannot remove refresh policy for %s: %w", r.table, err)
+		}
+
+		window := cfg.rollupWindow(r)
+
+		err = db.Exec(`
+			SELECT add_continuous_aggregate_policy(
+				CAST(? AS regclass),
+				start_offset => CAST(? AS interval),
+				end_offset => CAST(? AS interval),
+				schedule_interval => CAST(? AS interval)
+			)
+		`, r.table, intervalLiteral(window), intervalLiteral(r.endOffset), intervalLiteral(r.schedule)).Error
+		if err != nil {
+			return fmt.Errorf("cannot add refresh policy for %s: %w", r.table, err)
+		}
+
+		log.Printf("[DB] Continuous aggregate %s refreshes the last %s", r.table, window)
+	}
+
+	return nil
+}
+
+func applyCompressionPolicy(db *gorm.DB, after time.Duration) error {
+	if err := db.Exec("SELECT remove_compression_policy('records', if_exists => TRUE)").Error; err != nil {
+		return fmt.Errorf("cannot remove records compression policy: %w", err)
+	}
+
+	if after <= 0 {
+		log.Println("[DB] Records compression disabled")
+		return nil
+	}
+
+	var enabled bool
+	err := db.Raw(`
+		SELECT compression_enabled FROM timescaledb_information.hypertables
+		WHERE hypertable_name = 'records'
+	`).Scan(&enabled).Error
+	if err != nil {
+		return err
+	}
+
+	if !enabled {
+		err := db.Exec(`
+			ALTER TABLE records SET (
+				timescaledb.compress,
+				timescaledb.compress_segmentby = 'sensor_id',
+				timescaledb.compress_orderby = 'created_at DESC'
+			)
+		`).Error
+		if err != nil {
+			return fmt.Errorf("cannot enable records compression: %w", err)
+		}
+	}
+
+	err = db.Exec("SELECT add_compression_policy('records', compress_after => CAST(? AS interval))", intervalLiteral(after)).Error
+	if err != nil {
+		return fmt.Errorf("cannot add records compression policy: %w", err)
+	}
+
+	log.Printf("[DB] Records older than %s are compressed", after)
+	return nil
+}
+
+func applyRetentionPolicy(db *gorm.DB, retainFor time.Duration) error {
+	if err := db.Exec("SELECT remove_retention_policy('records', if_exists => TRUE)").Error; err != nil {
+		return fmt.Errorf("cannot remove records retention policy: %w", err)
+	}
+
+	if retainFor <= 0 {
+		log.Println("[DB] Records retention disabled, data is kept forever")
+		return nil
+	}
+
+	err := db.Exec("SELECT add_retention_policy('records', drop_after => CAST(? AS interval))", intervalLiteral(retainFor)).Error
+	if err != nil {
+		return fmt.Errorf("cannot add records retention policy: %w", err)
+	}
+
+	log.Printf("[DB] Records older than %s are dropped", retainFor)
+	return nil
+}
@@ -0,0 +1,137 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordsHypertable(t *testing.T) {
+	gormDb, cleanUp, err := TestDB()
+	if err != nil {
+		t.Fatal("cannot setup db")
+	}
+	defer cleanUp()
+
+	isHypertable, err := recordsIsHypertable(gormDb)
+	assert.Nil(t, err)
+	assert.True(t, isHypertable)
+
+	var primaryKey []string
+	err = gormDb.Raw(`
+		SELECT column_name FROM information_schema.key_column_usage
+		WHERE table_name = 'records' AND constraint_name = 'records_pkey'
+		ORDER BY ordinal_position
+	`).Scan(&primaryKey).Error
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"id", "created_at"}, primaryKey)
+
+	assert.True(t, gormDb.Migrator().HasIndex(&Record{}, "idx_records_sensor_id_created_at"))
+
+	assert.Nil(t, migrateRecordsHypertable(gormDb))
+}
+
+func TestStoragePolicyConfigValidate(t *testing.T) {
+	assert.Nil(t, (&StoragePolicyConfig{}).Validate())
+	assert.Nil(t, (&StoragePolicyConfig{RetainFor: 30 * 24 * time.Hour}).Validate())
+	assert.Nil(t, (&StoragePolicyConfig{
+		RetainFor:          2 * 24 * time.Hour,
+		SecondRollupWindow: time.Hour,
+		MinuteRollupWindow: 24 * time.Hour,
+	}).Validate())
+
+	assert.NotNil(t, (&StoragePolicyConfig{RetainFor: 7 * 24 * time.Hour}).Validate())
+	assert.NotNil(t, (&StoragePolicyConfig{RetainFor: 3 * time.Hour, MinuteRollupWindow: time.Hour}).Validate())
+	assert.NotNil(t, (&StoragePolicyConfig{SecondRollupWindow: 2 * time.Second}).Validate())
+	assert.NotNil(t, (&StoragePolicyConfig{MinuteRollupWindow: 2 * time.Minute}).Validate())
+}
+
+func TestApplyStoragePolicies(t *testing.T) {
+	gormDb, cleanUp, err := TestDB()
+	if err != nil {
+		t.Fatal("cannot setup db")
+	}
+	defer cleanUp()
+
+	policies := func() map[string]string {
+		var jobs []struct {
+			ProcName string
+			Config   string
+		}
+		gormDb.Raw(`
+			SELECT proc_name, config::text AS config FROM timescaledb_information.jobs
+			WHERE hypertable_name = 'records'
+		`).Scan(&jobs)
+
+		result := map[string]string{}
+		for _, job := range jobs {
+			result[job.ProcName] = job.Config
+		}
+		return result
+	}
+
+	err = ApplyStoragePolicies(gormDb, &StoragePolicyConfig{
+		ChunkInterval: 24 * time.Hour,
+		CompressAfter: 7 * 24 * time.Hour,
+		RetainFor:     90 * 24 * time.Hour,
+	})
+	assert.Nil(t, err)
+
+	jobs := policies()
+	assert.Contains(t, jobs, "policy_compression")
+	assert.Contains(t, jobs, "policy_retention")
+
+	err = ApplyStoragePolicies(gormDb, &StoragePolicyConfig{CompressAfter: 24 * time.Hour})
+	assert.Nil(t, err)
+
+	jobs = policies()
+	assert.Contains(t, jobs, "policy_compression")
+	assert.NotContains(t, jobs, "policy_retention")
+
+	assert.Nil(t, ApplyStoragePolicies(gormDb, &StoragePolicyConfig{}))
+	assert.Empty(t, policies())
+
+	err = ApplyStoragePolicies(gormDb, &StoragePolicyConfig{RetainFor: 24 * time.Hour})
+	assert.NotNil(t, err)
+}
+
+func TestApplyRefreshPolicies(t *testing.T) {
+	gormDb, cleanUp, err := TestDB()
+	if err != nil {
+		t.Fatal("cannot setup db")
+	}
+	defer cleanUp()
+
+	startOffsets := func() map[string]time.Duration {
+		var jobs []struct {
+			ViewName    string
+			StartOffset int64
+		}
+		gormDb.Raw(`
+			SELECT v.view_name,
+				EXTRACT(EPOCH FROM (j.config->>'start_offset')::interval)::bigint AS start_offset
+			FROM timescaledb_information.jobs j
+			JOIN timescaledb_information.continuous_aggregates v
+				ON v.materialization_hypertable_name = j.hypertable_name
+			WHERE j.proc_name = 'policy_refresh_continuous_aggregate'
+		`).Scan(&jobs)
+
+		result := map[string]time.Duration{}
+		for _, job := range jobs {
+			result[job.ViewName] = time.Duration(job.StartOffset) * time.Second
+		}
+		return result
+	}
+
+	err = ApplyStoragePolicies(gormDb, &StoragePolicyConfig{
+		RetainFor:          30 * 24 * time.Hour,
+		SecondRollupWindow: 12 * time.Hour,
+		MinuteRollupWindow: 14 * 24 * time.Hour,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]time.Duration{"records_1s": 12 * time.Hour, "records_1m": 14 * 24 * time.Hour}, startOffsets())
+
+	assert.Nil(t, ApplyStoragePolicies(gormDb, &StoragePolicyConfig{}))
+	assert.Equal(t, map[string]time.Duration{"records_1s": 6 * time.Hour, "records_1m": 7 * 24 * time.Hour}, startOffsets())
+}
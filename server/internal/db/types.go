@@ -1,13 +1,114 @@
 package db
 
-import "time"
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type ValueType uint
+
+// TypeInt64 and TypeUint64 are reserved: record values are stored as float64
+// and cannot hold them exactly.
+const (
+	TypeFloat32 ValueType = iota
+	TypeInt8
+	TypeInt16
+	TypeInt32
+	TypeInt64
+	TypeUint8
+	TypeUint16
+	TypeUint32
+	TypeUint64
+	TypeBool
+	TypeEnum
+	TypeScaledInt16
+	TypeScaledUint16
+	TypeScaledInt32
+	TypeScaledUint32
+	TypeFloat64
+)
+
+var valueTypeNames = map[ValueType]string{
+	TypeFloat32:      "float32",
+	TypeFloat64:      "float64",
+	TypeInt8:         "int8",
+	TypeInt16:        "int16",
+	TypeInt32:        "int32",
+	TypeInt64:        "int64",
+	TypeUint8:        "uint8",
+	TypeUint16:       "uint16",
+	TypeUint32:       "uint32",
+	TypeUint64:       "uint64",
+	TypeBool:         "bool",
+	TypeEnum:         "enum",
+	TypeScaledInt16:  "scaled_int16",
+	TypeScaledUint16: "scaled_uint16",
+	TypeScaledInt32:  "scaled_int32",
+	TypeScaledUint32: "scaled_uint32",
+}
+
+func ParseValueType(s string) (ValueType, error) {
+	for t, name := range valueTypeNames {
+		if name == strings.ToLower(s) {
+			return t, nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown value type %q", s)
+}
+
+func (t ValueType) Valid() bool {
+	_, ok := valueTypeNames[t]
+	return ok
+}
+
+func (t ValueType) Lossless() bool {
+	return t != TypeInt64 && t != TypeUint64
+}
+
+func (t ValueType) String() string {
+	if name, ok := valueTypeNames[t]; ok {
+		return name
+	}
+
+	return "unknown"
+}
 
 type Record struct {
-	ID        uint      `gorm:"primarykey" json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	Value     float32   `json:"value"`
+	ID        uint      `gorm:"primarykey;autoIncrement" json:"id"`
+	CreatedAt time.Time `gorm:"primarykey;index:idx_records_sensor_id_created_at,priority:2,sort:desc" json:"created_at"`
+	Value     float64   `json:"value"`
+	Type      ValueType `gorm:"default:0" json:"type"`
+	State     string    `gorm:"-" json:"state,omitempty"`
+
+	SensorID uint `gorm:"index:idx_records_sensor_id_created_at,priority:1" json:"sensor_id"`
+}
 
-	SensorID uint `json:"sensor_id"`
+func (r Record) TypedValue() any {
+	switch r.Type {
+	case TypeBool:
+		return r.Value != 0
+	case TypeInt8, TypeInt16, TypeInt32:
+		return int64(r.Value)
+	case TypeUint8, TypeUint16, TypeUint32, TypeEnum:
+		return uint64(r.Value)
+	default:
+		return r.Value
+	}
+}
+
+func (r Record) MarshalJSON() ([]byte, error) {
+	type record Record
+
+	return json.Marshal(struct {
+		record
+		Value any `json:"value"`
+	}{
+		record: record(r),
+		Value:  r.TypedValue(),
+	})
 }
 
 type Sensor struct {
@@ -15,10 +116,35 @@ type Sensor struct {
 	Name      string    `json:"name"`
 	CreatedAt time.Time `json:"created_at"`
 
+	ConfigID    uint       `gorm:"index" json:"config_id"`
+	Type        ValueType  `gorm:"default:0" json:"type"`
+	Unit        string     `json:"unit"`
+	Description string     `json:"description"`
+	Min         *float64   `json:"min"`
+	Max         *float64   `json:"max"`
+	Gain        float64    `json:"gain"`
+	Offset      float64    `json:"offset"`
+	States      []string   `gorm:"serializer:json" json:"states"`
+	ArchivedAt  *time.Time `gorm:"index" json:"archived_at"`
+
 	Records  []Record
 	ModuleID uint
 }
 
+func (s *Sensor) StateLabel(value float64) string {
+	if s.Type != TypeEnum || value < 0 || value != float64(int(value)) || int(value) >= len(s.States) {
+		return ""
+	}
+
+	return s.States[int(value)]
+}
+
+func (s *Sensor) LabelRecords() {
+	for i := range s.Records {
+		s.Records[i].State = s.StateLabel(s.Records[i].Value)
+	}
+}
+
 type Module struct {
 	ID   uint   `gorm:"primarykey" json:"id"`
 	Name string `json:"name"`
@@ -39,3 +165,83 @@ type User struct {
 	CreatedAt time.Time `json:"created_at"`
 	Username  string    `gorm:"index" json:"username"`
 }
+
+type Alert struct {
+	ID         uint       `gorm:"primarykey" json:"id"`
+	Rule       string     `gorm:"index" json:"rule"`
+	Kind       string     `json:"kind"`
+	Severity   string     `json:"severity"`
+	Section    string     `json:"section"`
+	Module     string     `json:"module"`
+	Sensor     string     `json:"sensor"`
+	Value      float64    `json:"value"`
+	Threshold  float64    `json:"threshold"`
+	FiredAt    time.Time  `gorm:"index" json:"fired_at"`
+	ResolvedAt *time.Time `gorm:"index" json:"resolved_at"`
+}
+
+func (a *Alert) Active() bool {
+	return a.ResolvedAt == nil
+}
+
+type Aggregate string
+
+const (
+	AggregateMin   Aggregate = "min"
+	AggregateMax   Aggregate = "max"
+	AggregateAvg   Aggregate = "avg"
+	AggregateLast  Aggregate = "last"
+	AggregateCount Aggregate = "count"
+)
+
+var aggregateColumns = map[Aggregate]string{
+	AggregateMin:   "min(value) AS min",
+	AggregateMax:   "max(value) AS max",
+	AggregateAvg:   "avg(value) AS avg",
+	AggregateLast:  "last(value, created_at) AS last",
+	AggregateCount: "count(*) AS count",
+}
+
+func ParseAggregate(s string) (Aggregate, error) {
+	aggregate := Aggregate(strings.ToLower(s))
+	if _, ok := aggregateColumns[aggregate]; !ok {
+		return "", fmt.Errorf("unknown aggregate %q, expected min, max, avg, last or count", s)
+	}
+
+	return aggregate, nil
+}
+
+type RecordBucket struct {
+	Time  time.Time `json:"time"`
+	Min   *float64  `json:"min,omitempty"`
+	Max   *float64  `json:"max,omitempty"`
+	Avg   *float64  `json:"avg,omitempty"`
+	Last  *float64  `json:"last,omitempty"`
+	Count *int64    `json:"count,omitempty"`
+}
+
+type LatestRecord struct {
+	SensorID  uint      `json:"sensor_id"`
+	Section   string    `json:"section"`
+	Module    string    `json:"module"`
+	Sensor    string    `json:"sensor"`
+	Value     float64   `json:"value"`
+	Type      ValueType `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (r LatestRecord) Record() Record {
+	return Record{
+		SensorID:  r.SensorID,
+		Value:     r.Value,
+		Type:      r.Type,
+		CreatedAt: r.CreatedAt,
+	}
+}
+
+type SensorPath struct {
+	SensorID uint   `json:"sensor_id"`
+	Section  string `json:"section"`
+	Module   string `json:"module"`
+	Sensor   string `json:"sensor"`
+}
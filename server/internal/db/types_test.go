@@ -0,0 +1,118 @@
+package db
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordMarshalJSON(t *testing.T) {
+	tests := []struct {
+		record   Record
+		expected any
+	}{
+		{
+			record:   Record{Value: 42.5, Type: TypeFloat32},
+			expected: 42.5,
+		},
+		{
+			record:   Record{Value: -3, Type: TypeInt16},
+			expected: float64(-3),
+		},
+		{
+			record:   Record{Value: 7, Type: TypeUint32},
+			expected: float64(7),
+		},
+		{
+			record:   Record{Value: 1, Type: TypeBool},
+			expected: true,
+		},
+		{
+			record:   Record{Value: 0, Type: TypeBool},
+			expected: false,
+		},
+		{
+			record:   Record{Value: 2, Type: TypeEnum},
+			expected: float64(2),
+		},
+		{
+			record:   Record{Value: 12.5, Type: TypeScaledInt16},
+			expected: 12.5,
+		},
+	}
+
+	for _, tt := range tests {
+		b, err := json.Marshal(tt.record)
+		assert.Nil(t, err)
+
+		decoded := make(map[string]any)
+		err = json.Unmarshal(b, &decoded)
+		assert.Nil(t, err)
+
+		assert.Equal(t, tt.expected, decoded["value"], tt.record.Type.String())
+		assert.Equal(t, float64(tt.record.Type), decoded["type"])
+	}
+}
+
+func TestRecordMarshalJSON_Integers(t *testing.T) {
+	b, err := json.Marshal(Record{Value: -3, Type: TypeInt16})
+	assert.Nil(t, err)
+	assert.Contains(t, string(b), `"value":-3`)
+
+	b, err = json.Marshal(Record{Value: 1, Type: TypeBool})
+	assert.Nil(t, err)
+	assert.Contains(t, string(b), `"value":true`)
+}
+
+func TestValueTypeValid(t *testing.T) {
+	assert.True(t, TypeFloat32.Valid())
+	assert.True(t, TypeScaledUint32.Valid())
+	assert.False(t, ValueType(999).Valid())
+	assert.Equal(t, "unknown", ValueType(999).String())
+	assert.Equal(t, "bool", TypeBool.String())
+	assert.False(t, TypeInt64.Lossless())
+	assert.True(t, TypeInt32.Lossless())
+}
+
+func TestParseValueType(t *testing.T) {
+	valueType, err := ParseValueType("scaled_int16")
+	assert.Nil(t, err)
+	assert.Equal(t, TypeScaledInt16, valueType)
+
+	valueType, err = ParseValueType("Float64")
+	assert.Nil(t, err)
+	assert.Equal(t, TypeFloat64, valueType)
+
+	_, err = ParseValueType("int128")
+	assert.Error(t, err)
+}
+
+func TestSensorLabelRecords(t *testing.T) {
+	sensor := &Sensor{
+		Type:   TypeEnum,
+		States: []string{"IDLE", "RUNNING"},
+		Records: []Record{
+			{Value: 1, Type: TypeEnum},
+			{Value: 0, Type: TypeEnum},
+			{Value: 7, Type: TypeEnum},
+		},
+	}
+	sensor.LabelRecords()
+
+	assert.Equal(t, "RUNNING", sensor.Records[0].State)
+	assert.Equal(t, "IDLE", sensor.Records[1].State)
+	assert.Equal(t, "", sensor.Records[2].State)
+
+	b, err := json.Marshal(sensor.Records[0])
+	assert.Nil(t, err)
+	assert.Contains(t, string(b), `"state":"RUNNING"`)
+
+	b, err = json.Marshal(sensor.Records[2])
+	assert.Nil(t, err)
+	assert.NotContains(t, string(b), `"state"`)
+
+	plain := &Sensor{Type: TypeUint8, States: []string{"IDLE"}, Records: []Record{{Value: 0}}}
+	plain.LabelRecords()
+	assert.Equal(t, "", plain.Records[0].State)
+}
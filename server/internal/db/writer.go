@@ -0,0 +1,244 @@
+package db
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	ErrWriterClosed = errors.New("record writer closed")
+	ErrQueueFull    = errors.New("record queue full")
+)
+
+type RecordWriterConfig struct {
+	BatchSize      int
+	FlushInterval  time.Duration
+	QueueSize      int
+	EnqueueTimeout time.Duration
+	FlushRetries   int
+	RetryBackoff   time.Duration
+}
+
+type RecordWriterStats struct {
+	Enqueued      uint64 `json:"enqueued"`
+	Written       uint64 `json:"written"`
+	Dropped       uint64 `json:"dropped"`
+	Failed        uint64 `json:"failed"`
+	Retries       uint64 `json:"retries"`
+	Flushes       uint64 `json:"flushes"`
+	Blocked       uint64 `json:"blocked"`
+	QueueLength   int    `json:"queue_length"`
+	QueueCapacity int    `json:"queue_capacity"`
+}
+
+type RecordWriter struct {
+	insert         func(records []Record, batchSize int) error
+	queue          chan Record
+	batchSize      int
+	flushInterval  time.Duration
+	enqueueTimeout time.Duration
+	flushRetries   int
+	retryBackoff   time.Duration
+
+	mu     sync.RWMutex
+	closed bool
+	done   chan struct{}
+
+	enqueued atomic.Uint64
+	written  atomic.Uint64
+	dropped  atomic.Uint64
+	failed   atomic.Uint64
+	retries  atomic.Uint64
+	flushes  atomic.Uint64
+	blocked  atomic.Uint64
+}
+
+func NewRecordWriter(db *DB, cfg *RecordWriterConfig) *RecordWriter {
+	return newRecordWriter(db.InsertRecords, cfg)
+}
+
+func newRecordWriter(insert func(records []Record, batchSize int) error, cfg *RecordWriterConfig) *RecordWriter {
+	batchSize := cfg.BatchSize
+	if batchSize <= 0 {
+		log.Println("[DB] Record writer batch size not set, using 500")
+		batchSize = 500
+	}
+
+	flushInterval := cfg.FlushInterval
+	if flushInterval <= 0 {
+		log.Println("[DB] Record writer flush interval not set, using 1s")
+		flushInterval = time.Second
+	}
+
+	queueSize := cfg.QueueSize
+	if queueSize < batchSize {
+		log.Printf("[DB] Record writer queue size %d smaller than batch size, using %d", queueSize, batchSize*4)
+		queueSize = batchSize * 4
+	}
+
+	flushRetries := cfg.FlushRetries
+	if flushRetries <= 0 {
+		log.Println("[DB] Record writer flush retries not set, using 3")
+		flushRetries = 3
+	}
+
+	retryBackoff := cfg.RetryBackoff
+	if retryBackoff <= 0 {
+		log.Println("[DB] Record writer retry backoff not set, using 100ms")
+		retryBackoff = 100 * time.Millisecond
+	}
+
+	w := &RecordWriter{
+		insert:         insert,
+		queue:          make(chan Record, queueSize),
+		batchSize:      batchSize,
+		flushInterval:  flushInterval,
+		enqueueTimeout: cfg.EnqueueTimeout,
+		flushRetries:   flushRetries,
+		retryBackoff:   retryBackoff,
+		done:           make(chan struct{}),
+	}
+
+	log.Printf("[DB] Starting record writer - BatchSize: %d, FlushInterval: %s, QueueSize: %d",
+		batchSize, flushInterval, queueSize)
+
+	go w.run()
+
+	return w
+}
+
+func (w *RecordWriter) Write(record Record) error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if w.closed {
+		return ErrWriterClosed
+	}
+
+	select {
+	case w.queue <- record:
+		w.enqueued.Add(1)
+		return nil
+	default:
+	}
+
+	w.blocked.Add(1)
+
+	if w.enqueueTimeout > 0 {
+		timer := time.NewTimer(w.enqueueTimeout)
+		defer timer.Stop()
+
+		select {
+		case w.queue <- record:
+			w.enqueued.Add(1)
+			return nil
+		case <-timer.C:
+		}
+	}
+
+	w.dropped.Add(1)
+	log.Printf("[DB] Record queue full, dropping record - SensorID: %d", record.SensorID)
+	return ErrQueueFull
+}
+
+func (w *RecordWriter) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return ErrWriterClosed
+	}
+	w.closed = true
+	close(w.queue)
+	w.mu.Unlock()
+
+	log.Println("[DB] Closing record writer, flushing queued records")
+	<-w.done
+
+	stats := w.Stats()
+	log.Printf("[DB] Record writer closed - Written: %d, Dropped: %d, Failed: %d",
+		stats.Written, stats.Dropped, stats.Failed)
+	return nil
+}
+
+func (w *RecordWriter) Stats() RecordWriterStats {
+	return RecordWriterStats{
+		Enqueued:      w.enqueued.Load(),
+		Written:       w.written.Load(),
+		Dropped:       w.dropped.Load(),
+		Failed:        w.failed.Load(),
+		Retries:       w.retries.Load(),
+		Flushes:       w.flushes.Load(),
+		Blocked:       w.blocked.Load(),
+		QueueLength:   len(w.queue),
+		QueueCapacity: cap(w.queue),
+	}
+}
+
+func (w *RecordWriter) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]Record, 0, w.batchSize)
+
+	for {
+		select {
+		case record, ok := <-w.queue:
+			if !ok {
+				w.flush(batch)
+				return
+			}
+
+			batch = append(batch, record)
+			if len(batch) >= w.batchSize {
+				w.flush(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				w.flush(batch)
+				batch = batch[:0]
+			}
+		}
+	}
+}
+
+func (w *RecordWriter) flush(batch []Record) {
+	if len(batch) == 0 {
+		return
+	}
+
+	w.flushes.Add(1)
+
+	backoff := w.retryBackoff
+	for attempt := 0; ; attempt++ {
+		err := w.insert(batch, w.batchSize)
+		if err == nil {
+			break
+		}
+
+		if attempt == w.flushRetries {
+			w.failed.Add(uint64(len(batch)))
+			log.Printf("[DB] Dropping %d records after %d failed flush attempts: %v", len(batch), attempt+1, err)
+			return
+		}
+
+		log.Printf("[DB] Error flushing %d records, retrying in %s: %v", len(batch), backoff, err)
+		w.retries.Add(1)
+		time.Sleep(backoff)
+		backoff *= 2
+
+		// The failed insert ran in a rolled back transaction, the ids
+		// it assigned do not exist.
+		for i := range batch {
+			batch[i].ID = 0
+		}
+	}
+
+	w.written.Add(uint64(len(batch)))
+	log.Printf("[DB] Flushed %d records - Queue: %d/%d", len(batch), len(w.queue), cap(w.queue))
+}
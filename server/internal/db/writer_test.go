@@ -0,0 +1,185 @@
+package db
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordWriter_FlushOnClose(t *testing.T) {
+	gormDb, cleanUp, err := TestDB()
+	if err != nil {
+		t.Fatal("cannot setup db")
+	}
+	defer cleanUp()
+
+	db := NewDB(gormDb)
+
+	section := &Section{
+		Name: "Trial",
+	}
+	gormDb.Create(section)
+
+	module := &Module{
+		Name:      "Trial",
+		SectionID: section.ID,
+	}
+	gormDb.Create(module)
+
+	sensor := &Sensor{
+		Name:     "Trial",
+		ModuleID: module.ID,
+	}
+	gormDb.Create(sensor)
+
+	writer := NewRecordWriter(db, &RecordWriterConfig{
+		BatchSize:     100,
+		FlushInterval: time.Hour,
+		QueueSize:     1000,
+	})
+
+	for i := range 250 {
+		err := writer.Write(Record{
+			Value:     float64(i),
+			SensorID:  sensor.ID,
+			CreatedAt: time.Now(),
+		})
+		assert.Nil(t, err)
+	}
+
+	err = writer.Close()
+	assert.Nil(t, err)
+
+	var count int64
+	gormDb.Model(&Record{}).Where("sensor_id = ?", sensor.ID).Count(&count)
+	assert.Equal(t, int64(250), count)
+
+	stats := writer.Stats()
+	assert.Equal(t, uint64(250), stats.Enqueued)
+	assert.Equal(t, uint64(250), stats.Written)
+	assert.Equal(t, uint64(0), stats.Dropped)
+	assert.Equal(t, uint64(3), stats.Flushes)
+}
+
+func TestRecordWriter_FlushOnInterval(t *testing.T) {
+	gormDb, cleanUp, err := TestDB()
+	if err != nil {
+		t.Fatal("cannot setup db")
+	}
+	defer cleanUp()
+
+	db := NewDB(gormDb)
+
+	section := &Section{
+		Name: "Trial",
+	}
+	gormDb.Create(section)
+
+	module := &Module{
+		Name:      "Trial",
+		SectionID: section.ID,
+	}
+	gormDb.Create(module)
+
+	sensor := &Sensor{
+		Name:     "Trial",
+		ModuleID: module.ID,
+	}
+	gormDb.Create(sensor)
+
+	writer := NewRecordWriter(db, &RecordWriterConfig{
+		BatchSize:     100,
+		FlushInterval: 50 * time.Millisecond,
+		QueueSize:     1000,
+	})
+	defer writer.Close()
+
+	err = writer.Write(Record{
+		Value:     42,
+		SensorID:  sensor.ID,
+		CreatedAt: time.Now(),
+	})
+	assert.Nil(t, err)
+
+	assert.Eventually(t, func() bool {
+		var count int64
+		gormDb.Model(&Record{}).Where("sensor_id = ?", sensor.ID).Count(&count)
+		return count == 1
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestRecordWriter_WriteAfterClose(t *testing.T) {
+	writer := NewRecordWriter(NewDB(nil), &RecordWriterConfig{
+		BatchSize:     10,
+		FlushInterval: time.Hour,
+		QueueSize:     10,
+	})
+
+	err := writer.Close()
+	assert.Nil(t, err)
+
+	err = writer.Write(Record{Value: 42})
+	assert.ErrorIs(t, err, ErrWriterClosed)
+
+	err = writer.Close()
+	assert.ErrorIs(t, err, ErrWriterClosed)
+}
+
+func TestRecordWriter_RetryFailedFlush(t *testing.T) {
+	var attempts int
+	var written []Record
+
+	writer := newRecordWriter(func(records []Record, batchSize int) error {
+		attempts++
+		if attempts < 3 {
+			return errors.New("connection refused")
+		}
+		written = append(written, records...)
+		return nil
+	}, &RecordWriterConfig{
+		BatchSize:     10,
+		FlushInterval: time.Hour,
+		QueueSize:     10,
+		FlushRetries:  3,
+		RetryBackoff:  time.Millisecond,
+	})
+
+	assert.Nil(t, writer.Write(Record{Value: 42}))
+	assert.Nil(t, writer.Close())
+
+	assert.Equal(t, 3, attempts)
+	assert.Len(t, written, 1)
+
+	stats := writer.Stats()
+	assert.Equal(t, uint64(1), stats.Written)
+	assert.Equal(t, uint64(0), stats.Failed)
+	assert.Equal(t, uint64(2), stats.Retries)
+}
+
+func TestRecordWriter_DropAfterRetries(t *testing.T) {
+	var attempts int
+
+	writer := newRecordWriter(func(records []Record, batchSize int) error {
+		attempts++
+		return errors.New("connection refused")
+	}, &RecordWriterConfig{
+		BatchSize:     10,
+		FlushInterval: time.Hour,
+		QueueSize:     10,
+		FlushRetries:  2,
+		RetryBackoff:  time.Millisecond,
+	})
+
+	assert.Nil(t, writer.Write(Record{Value: 1}))
+	assert.Nil(t, writer.Write(Record{Value: 2}))
+	assert.Nil(t, writer.Close())
+
+	assert.Equal(t, 3, attempts)
+
+	stats := writer.Stats()
+	assert.Equal(t, uint64(0), stats.Written)
+	assert.Equal(t, uint64(2), stats.Failed)
+	assert.Equal(t, uint64(2), stats.Retries)
+}
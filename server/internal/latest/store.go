@@ -0,0 +1,292 @@
+package latest
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ApexCorse/ephoros/server/internal/bus"
+	"github.com/ApexCorse/ephoros/server/internal/config"
+	"github.com/ApexCorse/ephoros/server/internal/db"
+)
+
+const TopicPrefix = config.ReservedSection + "/latest/"
+
+type Publisher interface {
+	Publish(topic string, payload []byte, retain bool, qos byte) error
+}
+
+type Value struct {
+	SensorID  uint      `json:"sensor_id"`
+	Section   string    `json:"section"`
+	Module    string    `json:"module"`
+	Sensor    string    `json:"sensor"`
+	Value     any       `json:"value"`
+	Type      string    `json:"type"`
+	State     string    `json:"state,omitempty"`
+	Unit      string    `json:"unit,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+func (v *Value) Path() string {
+	return v.Section + "/" + v.Module + "/" + v.Sensor
+}
+
+type StoreConfig struct {
+	Config      *config.Config
+	Publisher   Publisher
+	MinInterval time.Duration
+}
+
+type Store struct {
+	publisher   Publisher
+	minInterval time.Duration
+	now         func() time.Time
+
+	mu        sync.Mutex
+	units     map[string]string
+	enums     map[string]*db.Sensor
+	values    map[string]Value
+	published map[string]time.Time
+	pending   map[string]bool
+}
+
+func NewStore(cfg *StoreConfig) *Store {
+	if cfg.Publisher == nil {
+		log.Println("[LATEST] publisher is nil, latest values will not be published over MQTT")
+	}
+
+	s := &Store{
+		publisher:   cfg.Publisher,
+		minInterval: cfg.MinInterval,
+		now:         time.Now,
+		values:      make(map[string]Value),
+		published:   make(map[string]time.Time),
+		pending:     make(map[string]bool),
+	}
+	s.SetConfig(cfg.Config)
+
+	return s
+}
+
+func (s *Store) SetConfig(cfg *config.Config) {
+	units := make(map[string]string)
+	enums := make(map[string]*db.Sensor)
+	if cfg != nil {
+		for _, sConfig := range cfg.SensorConfigs {
+			units[sConfig.Path()] = sConfig.Unit
+
+			if valueType, _ := sConfig.ValueType(); valueType == db.TypeEnum {
+				enums[sConfig.Path()] = &db.Sensor{Type: db.TypeEnum, States: sConfig.States}
+			}
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.units = units
+	s.enums = enums
+
+	removed := 0
+	for path, value := range s.values {
+		if _, ok := units[path]; ok || cfg == nil {
+			if value.Unit != units[path] {
+				value.Unit = units[path]
+				s.values[path] = value
+				s.pending[path] = true
+			}
+			continue
+		}
+
+		delete(s.values, path)
+		delete(s.published, path)
+		delete(s.pending, path)
+		s.clear(path)
+		removed++
+	}
+
+	log.Printf("[LATEST] Configuration updated - Sensors: %d, Cleared: %d", len(units), removed)
+}
+
+func (s *Store) Handle(sample bus.Sample) {
+	timestamp := sample.Record.CreatedAt
+	if timestamp.IsZero() {
+		timestamp = s.now()
+	}
+
+	path := sample.Path()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if current, ok := s.values[path]; ok && timestamp.Before(current.Timestamp) {
+		return
+	}
+
+	record := sample.Record
+	record.CreatedAt = timestamp
+
+	value := newValue(sample.Section, sample.Module, sample.Sensor, record)
+	value.Unit = s.units[path]
+	s.values[path] = value
+
+	if s.now().Sub(s.published[path]) < s.minInterval {
+		s.pending[path] = true
+		return
+	}
+
+	s.publish(path)
+}
+
+func (s *Store) Restore(database *db.DB) error {
+	records, err := database.GetLatestRecords()
+	if err != nil {
+		log.Printf("[LATEST] Error loading latest records: %v", err)
+		return err
+	}
+
+	s.seed(s.restoredValues(records))
+	return nil
+}
+
+// Stored records carry no state label, it is taken from the configuration
+// the same way the ingest path does.
+func (s *Store) restoredValues(records []db.LatestRecord) []Value {
+	s.mu.Lock()
+	enums := s.enums
+	s.mu.Unlock()
+
+	values := make([]Value, 0, len(records))
+	for _, latest := range records {
+		record := latest.Record()
+		if sensor, ok := enums[latest.Section+"/"+latest.Module+"/"+latest.Sensor]; ok {
+			record.State = sensor.StateLabel(record.Value)
+		}
+
+		values = append(values, newValue(latest.Section, latest.Module, latest.Sensor, record))
+	}
+
+	return values
+}
+
+func (s *Store) seed(values []Value) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	seeded := 0
+	for _, value := range values {
+		path := value.Path()
+		if current, ok := s.values[path]; ok && !value.Timestamp.After(current.Timestamp) {
+			continue
+		}
+
+		value.Unit = s.units[path]
+		s.values[path] = value
+		s.pending[path] = true
+		seeded++
+	}
+
+	log.Printf("[LATEST] Restored %d latest values", seeded)
+}
+
+func (s *Store) Run(ctx context.Context) {
+	interval := s.minInterval
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	log.Printf("[LATEST] Publishing latest values at most every %s", s.minInterval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("[LATEST] Latest value publisher stopped")
+			return
+		case <-ticker.C:
+			s.Flush()
+		}
+	}
+}
+
+func (s *Store) Flush() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for path := range s.pending {
+		s.publish(path)
+	}
+}
+
+func (s *Store) Get(section, module, sensor string) (Value, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	value, ok := s.values[section+"/"+module+"/"+sensor]
+	return value, ok
+}
+
+func (s *Store) Values() []Value {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	values := make([]Value, 0, len(s.values))
+	for _, value := range s.values {
+		values = append(values, value)
+	}
+
+	slices.SortFunc(values, func(a, b Value) int {
+		return strings.Compare(a.Path(), b.Path())
+	})
+
+	return values
+}
+
+func newValue(section, module, sensor string, record db.Record) Value {
+	return Value{
+		SensorID:  record.SensorID,
+		Section:   section,
+		Module:    module,
+		Sensor:    sensor,
+		Value:     record.TypedValue(),
+		Type:      record.Type.String(),
+		State:     record.State,
+		Timestamp: record.CreatedAt,
+	}
+}
+
+func (s *Store) publish(path string) {
+	delete(s.pending, path)
+	s.published[path] = s.now()
+
+	if s.publisher == nil {
+		return
+	}
+
+	payload, err := json.Marshal(s.values[path])
+	if err != nil {
+		log.Printf("[LATEST] Error encoding latest value for %s: %v", path, err)
+		return
+	}
+
+	if err := s.publisher.Publish(TopicPrefix+path, payload, true, 0); err != nil {
+		log.Printf("[LATEST] Error publishing latest value for %s: %v", path, err)
+	}
+}
+
+func (s *Store) clear(path string) {
+	if s.publisher == nil {
+		return
+	}
+
+	if err := s.publisher.Publish(TopicPrefix+path, []byte{}, true, 0); err != nil {
+		log.Printf("[LATEST] Error clearing latest value for %s: %v", path, err)
+	}
+}
@@ -0,0 +1,201 @@
+package latest
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ApexCorse/ephoros/server/internal/bus"
+	"github.com/ApexCorse/ephoros/server/internal/config"
+	"github.com/ApexCorse/ephoros/server/internal/db"
+	"github.com/stretchr/testify/assert"
+)
+
+type publishedMessage struct {
+	topic   string
+	payload []byte
+	retain  bool
+}
+
+type fakePublisher struct {
+	mu       sync.Mutex
+	messages []publishedMessage
+}
+
+func (p *fakePublisher) Publish(topic string, payload []byte, retain bool, qos byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.messages = append(p.messages, publishedMessage{topic: topic, payload: payload, retain: retain})
+	return nil
+}
+
+func (p *fakePublisher) take() []publishedMessage {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	messages := p.messages
+	p.messages = nil
+	return messages
+}
+
+var testConfig = &config.Config{
+	SensorConfigs: []config.SensorConfig{
+		{Name: "NTC-1", Section: "Battery", Module: "Module 1", Unit: "°C"},
+		{Name: "Enabled", Section: "Vehicle", Module: "ECU"},
+		{Name: "Mode", Section: "Inverter", Module: "Control", Encoding: "enum", States: []string{"idle", "drive", "fault"}},
+	},
+}
+
+func newTestStore(minInterval time.Duration) (*Store, *fakePublisher, *time.Time) {
+	publisher := &fakePublisher{}
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	s := NewStore(&StoreConfig{Config: testConfig, Publisher: publisher, MinInterval: minInterval})
+	s.now = func() time.Time { return now }
+
+	return s, publisher, &now
+}
+
+func sample(section, module, sensor string, record db.Record) bus.Sample {
+	return bus.Sample{Section: section, Module: module, Sensor: sensor, Record: record}
+}
+
+func TestStore_Handle(t *testing.T) {
+	s, publisher, now := newTestStore(0)
+
+	s.Handle(sample("Battery", "Module 1", "NTC-1", db.Record{SensorID: 3, Value: 42, Type: db.TypeInt16, CreatedAt: *now}))
+	s.Handle(sample("Vehicle", "ECU", "Enabled", db.Record{SensorID: 4, Value: 1, Type: db.TypeBool, CreatedAt: *now}))
+
+	messages := publisher.take()
+	assert.Len(t, messages, 2)
+	assert.Equal(t, "ephoros/latest/Battery/Module 1/NTC-1", messages[0].topic)
+	assert.True(t, messages[0].retain)
+	assert.JSONEq(t, `{
+		"sensor_id": 3,
+		"section": "Battery",
+		"module": "Module 1",
+		"sensor": "NTC-1",
+		"value": 42,
+		"type": "int16",
+		"unit": "°C",
+		"timestamp": "2025-06-01T12:00:00Z"
+	}`, string(messages[0].payload))
+
+	enabled := Value{}
+	assert.NoError(t, json.Unmarshal(messages[1].payload, &enabled))
+	assert.Equal(t, true, enabled.Value)
+
+	value, ok := s.Get("Battery", "Module 1", "NTC-1")
+	assert.True(t, ok)
+	assert.Equal(t, int64(42), value.Value)
+
+	values := s.Values()
+	assert.Len(t, values, 2)
+	assert.Equal(t, "Battery/Module 1/NTC-1", values[0].Path())
+
+	s.Handle(sample("Battery", "Module 1", "NTC-1", db.Record{Value: 40, Type: db.TypeInt16, CreatedAt: now.Add(-time.Second)}))
+	assert.Empty(t, publisher.take(), "older samples must not replace the latest value")
+}
+
+func TestStore_Throttle(t *testing.T) {
+	s, publisher, now := newTestStore(time.Second)
+
+	for i := range 5 {
+		*now = now.Add(100 * time.Millisecond)
+		s.Handle(sample("Battery", "Module 1", "NTC-1", db.Record{Value: float64(i), CreatedAt: *now}))
+	}
+
+	messages := publisher.take()
+	assert.Len(t, messages, 1)
+
+	s.Flush()
+	messages = publisher.take()
+	assert.Len(t, messages, 1)
+
+	value := Value{}
+	assert.NoError(t, json.Unmarshal(messages[0].payload, &value))
+	assert.Equal(t, 4.0, value.Value)
+
+	s.Flush()
+	assert.Empty(t, publisher.take())
+
+	*now = now.Add(time.Second)
+	s.Handle(sample("Battery", "Module 1", "NTC-1", db.Record{Value: 5, CreatedAt: *now}))
+	assert.Len(t, publisher.take(), 1)
+}
+
+func TestStore_SetConfig(t *testing.T) {
+	s, publisher, now := newTestStore(0)
+
+	s.Handle(sample("Battery", "Module 1", "NTC-1", db.Record{Value: 42, CreatedAt: *now}))
+	s.Handle(sample("Vehicle", "ECU", "Enabled", db.Record{Value: 1, CreatedAt: *now}))
+	publisher.take()
+
+	s.SetConfig(&config.Config{
+		SensorConfigs: []config.SensorConfig{
+			{Name: "NTC-1", Section: "Battery", Module: "Module 1", Unit: "K"},
+		},
+	})
+
+	messages := publisher.take()
+	assert.Len(t, messages, 1)
+	assert.Equal(t, "ephoros/latest/Vehicle/ECU/Enabled", messages[0].topic)
+	assert.Empty(t, messages[0].payload, "removed sensors clear their retained message")
+	assert.True(t, messages[0].retain)
+
+	_, ok := s.Get("Vehicle", "ECU", "Enabled")
+	assert.False(t, ok)
+
+	s.Flush()
+	messages = publisher.take()
+	assert.Len(t, messages, 1)
+
+	value := Value{}
+	assert.NoError(t, json.Unmarshal(messages[0].payload, &value))
+	assert.Equal(t, "K", value.Unit)
+}
+
+func TestStore_Seed(t *testing.T) {
+	s, publisher, now := newTestStore(time.Second)
+
+	s.Handle(sample("Battery", "Module 1", "NTC-1", db.Record{Value: 42, CreatedAt: *now}))
+	publisher.take()
+
+	s.seed([]Value{
+		newValue("Battery", "Module 1", "NTC-1", db.Record{Value: 30, CreatedAt: now.Add(-time.Hour)}),
+		newValue("Vehicle", "ECU", "Enabled", db.Record{SensorID: 4, Value: 1, Type: db.TypeBool, CreatedAt: now.Add(-time.Hour)}),
+	})
+
+	value, ok := s.Get("Battery", "Module 1", "NTC-1")
+	assert.True(t, ok)
+	assert.Equal(t, 42.0, value.Value, "seeding must not replace fresher values")
+
+	value, ok = s.Get("Vehicle", "ECU", "Enabled")
+	assert.True(t, ok)
+	assert.Equal(t, true, value.Value)
+	assert.Equal(t, "bool", value.Type)
+
+	s.Flush()
+	messages := publisher.take()
+	assert.Len(t, messages, 1, "seeded values are republished as retained messages")
+	assert.Equal(t, "ephoros/latest/Vehicle/ECU/Enabled", messages[0].topic)
+}
+
+func TestStore_States(t *testing.T) {
+	s, publisher, now := newTestStore(0)
+
+	s.Handle(sample("Inverter", "Control", "Mode", db.Record{Value: 1, Type: db.TypeEnum, State: "drive", CreatedAt: *now}))
+
+	messages := publisher.take()
+	assert.Len(t, messages, 1)
+	assert.Contains(t, string(messages[0].payload), `"state":"drive"`)
+
+	values := s.restoredValues([]db.LatestRecord{
+		{Section: "Inverter", Module: "Control", Sensor: "Mode", Value: 2, Type: db.TypeEnum},
+		{Section: "Battery", Module: "Module 1", Sensor: "NTC-1", Value: 2},
+	})
+	assert.Equal(t, "fault", values[0].State)
+	assert.Equal(t, "", values[1].State)
+}
@@ -0,0 +1,107 @@
+package mqtt
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"sync/atomic"
+
+	"github.com/ApexCorse/ephoros/server/internal/config"
+	"github.com/ApexCorse/ephoros/server/internal/password"
+	mqtt "github.com/mochi-mqtt/server/v2"
+	"github.com/mochi-mqtt/server/v2/hooks/auth"
+	"github.com/mochi-mqtt/server/v2/packets"
+)
+
+type AuthHook struct {
+	mqtt.HookBase
+	ledger *auth.Ledger
+	users  atomic.Pointer[map[string]password.Verifier]
+}
+
+func NewAuthHook(cfg *config.Config) *AuthHook {
+	h := &AuthHook{
+		ledger: &auth.Ledger{},
+	}
+	h.SetConfig(cfg)
+
+	return h
+}
+
+func (h *AuthHook) ID() string {
+	return "ephoros-auth"
+}
+
+func (h *AuthHook) Provides(b byte) bool {
+	return bytes.Contains([]byte{
+		mqtt.OnConnectAuthenticate,
+		mqtt.OnACLCheck,
+	}, []byte{b})
+}
+
+func (h *AuthHook) SetConfig(cfg *config.Config) {
+	users := make(map[string]password.Verifier, len(cfg.MQTT))
+	hashed := 0
+	for _, mConfig := range cfg.MQTT {
+		verifier, err := password.Parse(mConfig.Password)
+		if err != nil {
+			log.Printf("[MQTT] Invalid password hash for user %s, user disabled: %v", mConfig.Username, err)
+			continue
+		}
+
+		if password.IsHash(mConfig.Password) {
+			hashed++
+		}
+
+		users[mConfig.Username] = verifier
+	}
+
+	h.users.Store(&users)
+	h.ledger.Update(buildLedger(cfg))
+
+	log.Printf("[MQTT] Auth updated - Users: %d, Hashed passwords: %d", len(users), hashed)
+}
+
+func (h *AuthHook) OnConnectAuthenticate(cl *mqtt.Client, pk packets.Packet) bool {
+	users := *h.users.Load()
+
+	if username, ok := certificateUsername(cl); ok {
+		if _, known := users[username]; !known {
+			log.Printf("[MQTT] Authentication failed for client %s: certificate for unknown user %q", cl.ID, username)
+			return false
+		}
+
+		log.Printf("[MQTT] Client %s authenticated by certificate as %s", cl.ID, username)
+		cl.Properties.Username = []byte(username)
+		return true
+	}
+
+	username := string(cl.Properties.Username)
+
+	verifier, ok := users[username]
+	if !ok {
+		log.Printf("[MQTT] Authentication failed for client %s: unknown user %q", cl.ID, username)
+		return false
+	}
+
+	if err := verifier.Verify(string(pk.Connect.Password)); err != nil {
+		log.Printf("[MQTT] Authentication failed for client %s: user %s: %v", cl.ID, username, err)
+		return false
+	}
+
+	return true
+}
+
+func (h *AuthHook) OnACLCheck(cl *mqtt.Client, topic string, write bool) bool {
+	if write && isReservedTopic(topic) {
+		log.Printf("[MQTT] Client %s denied publish on reserved topic %s", cl.ID, topic)
+		return false
+	}
+
+	_, ok := h.ledger.ACLOk(cl, topic, write)
+	return ok
+}
+
+func isReservedTopic(topic string) bool {
+	return topic == config.ReservedSection || strings.HasPrefix(topic, config.ReservedSection+"/")
+}
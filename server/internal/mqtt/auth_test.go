@@ -0,0 +1,93 @@
+package mqtt
+
+import (
+	"testing"
+
+	"github.com/ApexCorse/ephoros/server/internal/config"
+	"github.com/ApexCorse/ephoros/server/internal/password"
+	mqtt "github.com/mochi-mqtt/server/v2"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestAuthHook_HashedPasswords(t *testing.T) {
+	bcryptHash, err := password.Hash("hunter2", password.AlgorithmBcrypt, bcrypt.MinCost)
+	assert.NoError(t, err)
+
+	argon2idHash, err := password.Hash("wall", password.AlgorithmArgon2id, 1)
+	assert.NoError(t, err)
+
+	h := NewAuthHook(&config.Config{
+		MQTT: []config.MQTTUserConfig{
+			{Username: "car", Password: bcryptHash},
+			{Username: "pit", Password: argon2idHash},
+			{Username: "legacy", Password: "plain"},
+			{Username: "broken", Password: "$argon2id$v=19$m=0$x$y"},
+		},
+	})
+
+	tests := []struct {
+		username string
+		password string
+		allowed  bool
+	}{
+		{username: "car", password: "hunter2", allowed: true},
+		{username: "car", password: "hunter3", allowed: false},
+		{username: "car", password: bcryptHash, allowed: false},
+		{username: "pit", password: "wall", allowed: true},
+		{username: "pit", password: "", allowed: false},
+		{username: "pit", password: argon2idHash, allowed: false},
+		{username: "legacy", password: "plain", allowed: true},
+		{username: "legacy", password: "plai", allowed: false},
+		{username: "broken", password: "$argon2id$v=19$m=0$x$y", allowed: false},
+		{username: "unknown", password: "plain", allowed: false},
+		{username: "", password: "", allowed: false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.allowed, authOk(h, tt.username, tt.password), "%s/%s", tt.username, tt.password)
+	}
+}
+
+func TestAuthHook_Provides(t *testing.T) {
+	h := NewAuthHook(&config.Config{})
+
+	assert.True(t, h.Provides(mqtt.OnConnectAuthenticate))
+	assert.True(t, h.Provides(mqtt.OnACLCheck))
+	assert.False(t, h.Provides(mqtt.OnPublish))
+}
+
+func TestAuthHook_DeniesReservedPublishes(t *testing.T) {
+	m := NewMQTT(&MQTTConfig{
+		Config: &config.Config{
+			MQTT: []config.MQTTUserConfig{
+				{Username: "legacy", Password: "one"},
+				{Username: "pit", Password: "two", Publish: []string{"#"}, Subscribe: []string{"#"}},
+				{Username: "spoofer", Password: "three", Publish: []string{"ephoros/#"}},
+			},
+		},
+		Server: mqtt.New(nil),
+	})
+
+	tests := []struct {
+		username string
+		topic    string
+		write    bool
+		allowed  bool
+	}{
+		{username: "legacy", topic: "ephoros/alerts/cell-overtemp", write: true, allowed: false},
+		{username: "legacy", topic: "ephoros/latest/Battery/Pack/Current", write: true, allowed: false},
+		{username: "legacy", topic: "ephoros", write: true, allowed: false},
+		{username: "legacy", topic: "Battery/Pack/Current", write: true, allowed: true},
+		{username: "legacy", topic: "ephoros/#", write: false, allowed: true},
+		{username: "pit", topic: "ephoros/alerts/cell-overtemp", write: true, allowed: false},
+		{username: "pit", topic: "ephoros/alerts/#", write: false, allowed: true},
+		{username: "pit", topic: "ephoros-test/a/b", write: true, allowed: true},
+		{username: "spoofer", topic: "ephoros/latest/Battery/Pack/Current", write: true, allowed: false},
+	}
+
+	for _, tt := range tests {
+		cl := &mqtt.Client{ID: tt.username, Properties: mqtt.ClientProperties{Username: []byte(tt.username)}}
+		assert.Equal(t, tt.allowed, m.auth.OnACLCheck(cl, tt.topic, tt.write), "%s write=%t %s", tt.username, tt.write, tt.topic)
+	}
+}
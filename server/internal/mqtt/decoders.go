@@ -0,0 +1,152 @@
+package mqtt
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"github.com/ApexCorse/ephoros/server/internal/config"
+	"github.com/ApexCorse/ephoros/server/internal/db"
+)
+
+type Decoder interface {
+	Type() db.ValueType
+	Size() int
+	Decode(b []byte) float64
+	State(value float64) string
+}
+
+type decoderFactory func(cfg *config.SensorConfig) Decoder
+
+var decoders = map[db.ValueType]decoderFactory{
+	db.TypeFloat32: fixedDecoder(db.TypeFloat32, 4, decodeFloat32),
+	db.TypeFloat64: fixedDecoder(db.TypeFloat64, 8, func(b []byte) float64 {
+		return math.Float64frombits(binary.LittleEndian.Uint64(b))
+	}),
+	db.TypeInt8: fixedDecoder(db.TypeInt8, 1, func(b []byte) float64 {
+		return float64(int8(b[0]))
+	}),
+	db.TypeInt16: fixedDecoder(db.TypeInt16, 2, decodeInt16),
+	db.TypeInt32: fixedDecoder(db.TypeInt32, 4, decodeInt32),
+	db.TypeUint8: fixedDecoder(db.TypeUint8, 1, func(b []byte) float64 {
+		return float64(b[0])
+	}),
+	db.TypeUint16: fixedDecoder(db.TypeUint16, 2, decodeUint16),
+	db.TypeUint32: fixedDecoder(db.TypeUint32, 4, decodeUint32),
+	db.TypeBool: fixedDecoder(db.TypeBool, 1, func(b []byte) float64 {
+		if b[0] != 0 {
+			return 1
+		}
+		return 0
+	}),
+	db.TypeEnum:         enumDecoder,
+	db.TypeScaledInt16:  scaledDecoder(db.TypeScaledInt16, 2, decodeInt16),
+	db.TypeScaledUint16: scaledDecoder(db.TypeScaledUint16, 2, decodeUint16),
+	db.TypeScaledInt32:  scaledDecoder(db.TypeScaledInt32, 4, decodeInt32),
+	db.TypeScaledUint32: scaledDecoder(db.TypeScaledUint32, 4, decodeUint32),
+}
+
+var defaultDecoder = decoders[db.TypeFloat32](nil)
+
+func NewDecoder(cfg *config.SensorConfig) (Decoder, error) {
+	valueType, err := cfg.ValueType()
+	if err != nil {
+		return nil, err
+	}
+
+	factory, ok := decoders[valueType]
+	if !ok {
+		return nil, fmt.Errorf("unsupported sensor encoding: %s", valueType)
+	}
+
+	return factory(cfg), nil
+}
+
+type numericDecoder struct {
+	valueType db.ValueType
+	size      int
+	decode    func(b []byte) float64
+	gain      float64
+	offset    float64
+	enum      *db.Sensor
+}
+
+func (d *numericDecoder) Type() db.ValueType {
+	return d.valueType
+}
+
+func (d *numericDecoder) Size() int {
+	return d.size
+}
+
+func (d *numericDecoder) Decode(b []byte) float64 {
+	return d.decode(b)*d.gain + d.offset
+}
+
+func (d *numericDecoder) State(value float64) string {
+	if d.enum == nil {
+		return ""
+	}
+
+	return d.enum.StateLabel(value)
+}
+
+func enumDecoder(cfg *config.SensorConfig) Decoder {
+	return &numericDecoder{
+		valueType: db.TypeEnum,
+		size:      1,
+		decode: func(b []byte) float64 {
+			return float64(b[0])
+		},
+		gain: 1,
+		enum: &db.Sensor{Type: db.TypeEnum, States: cfg.States},
+	}
+}
+
+func fixedDecoder(valueType db.ValueType, size int, decode func(b []byte) float64) decoderFactory {
+	return func(_ *config.SensorConfig) Decoder {
+		return &numericDecoder{
+			valueType: valueType,
+			size:      size,
+			decode:    decode,
+			gain:      1,
+		}
+	}
+}
+
+func scaledDecoder(valueType db.ValueType, size int, decode func(b []byte) float64) decoderFactory {
+	return func(cfg *config.SensorConfig) Decoder {
+		gain := cfg.Gain
+		if gain == 0 {
+			gain = 1
+		}
+
+		return &numericDecoder{
+			valueType: valueType,
+			size:      size,
+			decode:    decode,
+			gain:      gain,
+			offset:    cfg.Offset,
+		}
+	}
+}
+
+func decodeFloat32(b []byte) float64 {
+	return float64(math.Float32frombits(binary.LittleEndian.Uint32(b)))
+}
+
+func decodeInt16(b []byte) float64 {
+	return float64(int16(binary.LittleEndian.Uint16(b)))
+}
+
+func decodeUint16(b []byte) float64 {
+	return float64(binary.LittleEndian.Uint16(b))
+}
+
+func decodeInt32(b []byte) float64 {
+	return float64(int32(binary.LittleEndian.Uint32(b)))
+}
+
+func decodeUint32(b []byte) float64 {
+	return float64(binary.LittleEndian.Uint32(b))
+}
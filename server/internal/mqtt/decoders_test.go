@@ -0,0 +1,207 @@
+package mqtt
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ApexCorse/ephoros/server/internal/config"
+	"github.com/ApexCorse/ephoros/server/internal/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewDecoder(t *testing.T) {
+	tests := []struct {
+		config   *config.SensorConfig
+		payload  []byte
+		size     int
+		expected float64
+	}{
+		{
+			config:   &config.SensorConfig{Encoding: "float32"},
+			payload:  []byte{0x00, 0x00, 0x28, 0x42},
+			size:     4,
+			expected: 42,
+		},
+		{
+			config:   &config.SensorConfig{Encoding: "float64"},
+			payload:  []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x45, 0x40},
+			size:     8,
+			expected: 42,
+		},
+		{
+			config:   &config.SensorConfig{Encoding: "int8"},
+			payload:  []byte{0xfe},
+			size:     1,
+			expected: -2,
+		},
+		{
+			config:   &config.SensorConfig{Encoding: "int16"},
+			payload:  []byte{0x18, 0xfc},
+			size:     2,
+			expected: -1000,
+		},
+		{
+			config:   &config.SensorConfig{Encoding: "int32"},
+			payload:  []byte{0xc0, 0xbd, 0xf0, 0xff},
+			size:     4,
+			expected: -1000000,
+		},
+		{
+			config:   &config.SensorConfig{Encoding: "uint8"},
+			payload:  []byte{0xfe},
+			size:     1,
+			expected: 254,
+		},
+		{
+			config:   &config.SensorConfig{Encoding: "uint16"},
+			payload:  []byte{0x18, 0xfc},
+			size:     2,
+			expected: 64536,
+		},
+		{
+			config:   &config.SensorConfig{Encoding: "uint32"},
+			payload:  []byte{0x40, 0x42, 0x0f, 0x00},
+			size:     4,
+			expected: 1000000,
+		},
+		{
+			config:   &config.SensorConfig{Encoding: "bool"},
+			payload:  []byte{0x02},
+			size:     1,
+			expected: 1,
+		},
+		{
+			config:   &config.SensorConfig{Encoding: "bool"},
+			payload:  []byte{0x00},
+			size:     1,
+			expected: 0,
+		},
+		{
+			config:   &config.SensorConfig{Encoding: "enum", States: []string{"IDLE", "RUNNING", "FAULT"}},
+			payload:  []byte{0x02},
+			size:     1,
+			expected: 2,
+		},
+		{
+			config:   &config.SensorConfig{Encoding: "scaled_int16", Gain: 0.1, Offset: -40},
+			payload:  []byte{0xf4, 0x01},
+			size:     2,
+			expected: 10,
+		},
+		{
+			config:   &config.SensorConfig{Encoding: "scaled_uint16"},
+			payload:  []byte{0xf4, 0x01},
+			size:     2,
+			expected: 500,
+		},
+		{
+			config:   &config.SensorConfig{Encoding: "scaled_int32", Gain: 0.001},
+			payload:  []byte{0x18, 0xfc, 0xff, 0xff},
+			size:     4,
+			expected: -1,
+		},
+		{
+			config:   &config.SensorConfig{Encoding: "scaled_uint32", Gain: 2, Offset: 1},
+			payload:  []byte{0x05, 0x00, 0x00, 0x00},
+			size:     4,
+			expected: 11,
+		},
+	}
+
+	for _, tt := range tests {
+		decoder, err := NewDecoder(tt.config)
+
+		assert.NoError(t, err)
+		valueType, _ := tt.config.ValueType()
+		assert.Equal(t, valueType, decoder.Type())
+		assert.Equal(t, tt.size, decoder.Size())
+		assert.InDelta(t, tt.expected, decoder.Decode(tt.payload), 1e-9, decoder.Type().String())
+	}
+}
+
+func TestNewDecoder_UnknownType(t *testing.T) {
+	for _, encoding := range []string{"int128", "int64", "uint64"} {
+		decoder, err := NewDecoder(&config.SensorConfig{Encoding: encoding})
+
+		assert.Error(t, err)
+		assert.Nil(t, decoder)
+	}
+}
+
+func TestNewDecoder_EnumState(t *testing.T) {
+	decoder, err := NewDecoder(&config.SensorConfig{Encoding: "enum", States: []string{"IDLE", "RUNNING", "FAULT"}})
+	assert.NoError(t, err)
+
+	assert.Equal(t, "FAULT", decoder.State(decoder.Decode([]byte{0x02})))
+	assert.Equal(t, "", decoder.State(decoder.Decode([]byte{0x07})))
+
+	plain, err := NewDecoder(&config.SensorConfig{Encoding: "uint8", States: []string{"IDLE"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "", plain.State(0))
+}
+
+// Configurations written before typed payloads used arbitrary "type" values
+// for float32 sensors, they have to keep decoding as float32.
+func TestNewDecoder_LegacyType(t *testing.T) {
+	for _, legacyType := range []uint{0, 1, 2, 3, 4, 11, 999} {
+		decoder, err := NewDecoder(&config.SensorConfig{Name: "Temperature-1", Type: legacyType})
+		assert.NoError(t, err)
+		assert.Equal(t, db.TypeFloat32, decoder.Type())
+
+		samples, err := decodePayload([]byte{0x65, 0x00, 0x00, 0x00, 0x00, 0x00, 0x28, 0x42}, decoder)
+		assert.NoError(t, err)
+		assert.Len(t, samples, 1)
+		assert.Equal(t, time.Unix(0x65000000, 0), samples[0].CreatedAt)
+		assert.Equal(t, 42.0, samples[0].Value)
+	}
+}
+
+func TestBuildDecoders(t *testing.T) {
+	cfg := &config.Config{
+		SensorConfigs: []config.SensorConfig{
+			{
+				Name:     "NTC-1",
+				Section:  "Battery",
+				Module:   "Module 1",
+				Encoding: "int16",
+			},
+			{
+				Name:     "Enabled",
+				Section:  "Vehicle",
+				Module:   "ECU",
+				Encoding: "bool",
+			},
+		},
+	}
+
+	hook := NewDataHook(&DataHookConfig{Config: cfg})
+
+	assert.Equal(t, db.TypeInt16, hook.decoderFor("Battery/Module 1/NTC-1").Type())
+	assert.Equal(t, db.TypeBool, hook.decoderFor("Vehicle/ECU/Enabled").Type())
+	assert.Equal(t, db.TypeFloat32, hook.decoderFor("Vehicle/ECU/Unknown").Type())
+}
+
+func TestBuildDecoders_LegacyConfig(t *testing.T) {
+	cfg, err := config.NewConfigFromReader(strings.NewReader(`{
+		"sensors": [
+			{"name": "Temperature-1", "id": 1, "section": "Engine", "module": "Thermal", "type": 2},
+			{"name": "Pressure-1", "id": 2, "section": "Engine", "module": "Hydraulic", "type": 3},
+			{"name": "Voltage-1", "id": 3, "section": "Electrical", "module": "Power", "type": 4}
+		],
+		"mqtt": [{"username": "admin", "password": "secure123"}]
+	}`))
+	assert.NoError(t, err)
+
+	hook := NewDataHook(&DataHookConfig{Config: cfg})
+
+	for _, path := range []string{"Engine/Thermal/Temperature-1", "Engine/Hydraulic/Pressure-1", "Electrical/Power/Voltage-1"} {
+		decoder := hook.decoderFor(path)
+		assert.Equal(t, db.TypeFloat32, decoder.Type())
+
+		samples, err := decodePayload([]byte{0x65, 0x00, 0x00, 0x00, 0x00, 0x00, 0x28, 0x42}, decoder)
+		assert.NoError(t, err, path)
+		assert.Len(t, samples, 1)
+		assert.Equal(t, 42.0, samples[0].Value)
+	}
+}
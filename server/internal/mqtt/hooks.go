@@ -1,13 +1,14 @@
 package mqtt
 
 import (
-	"bytes"
-	"encoding/binary"
+	"errors"
 	"fmt"
 	"log"
 	"strings"
-	"time"
+	"sync/atomic"
 
+	"github.com/ApexCorse/ephoros/server/internal/bus"
+	"github.com/ApexCorse/ephoros/server/internal/config"
 	"github.com/ApexCorse/ephoros/server/internal/db"
 	mqtt "github.com/mochi-mqtt/server/v2"
 	"github.com/mochi-mqtt/server/v2/packets"
@@ -19,9 +20,71 @@ type SensorData struct {
 	Sensor  string `json:"sensor"`
 }
 
+type DataHookConfig struct {
+	Config *config.Config
+	DB     *db.DB
+	Cache  *db.SensorCache
+	Bus    *bus.Bus
+}
+
 type DataHook struct {
 	mqtt.HookBase
-	db *db.DB
+	db       *db.DB
+	cache    *db.SensorCache
+	bus      *bus.Bus
+	decoders atomic.Pointer[map[string]Decoder]
+}
+
+func NewDataHook(cfg *DataHookConfig) *DataHook {
+	if cfg.DB == nil {
+		log.Println("[MQTT] db is nil, caution")
+	}
+
+	if cfg.Cache == nil {
+		log.Println("[MQTT] sensor cache is nil, sensors will be resolved from the database")
+	}
+
+	if cfg.Bus == nil {
+		log.Println("[MQTT] bus is nil, caution - decoded samples will be discarded")
+	}
+
+	if cfg.Config == nil {
+		log.Println("[MQTT] config is nil, every sensor will be decoded as float32")
+	}
+
+	h := &DataHook{
+		db:    cfg.DB,
+		cache: cfg.Cache,
+		bus:   cfg.Bus,
+	}
+	h.SetConfig(cfg.Config)
+
+	return h
+}
+
+func (h *DataHook) SetConfig(cfg *config.Config) {
+	decoders := buildDecoders(cfg)
+	h.decoders.Store(&decoders)
+}
+
+func buildDecoders(cfg *config.Config) map[string]Decoder {
+	decoders := make(map[string]Decoder)
+	if cfg == nil {
+		return decoders
+	}
+
+	for _, sConfig := range cfg.SensorConfigs {
+		decoder, err := NewDecoder(&sConfig)
+		if err != nil {
+			log.Printf("[MQTT] Error creating decoder for sensor %s, falling back to float32: %v", sConfig.Path(), err)
+			continue
+		}
+
+		decoders[sConfig.Path()] = decoder
+	}
+
+	log.Printf("[MQTT] Built %d sensor decoders", len(decoders))
+	return decoders
 }
 
 func (h *DataHook) ID() string {
@@ -33,6 +96,10 @@ func (h *DataHook) Provides(b byte) bool {
 }
 
 func (h *DataHook) OnPublish(cl *mqtt.Client, pk packets.Packet) (packets.Packet, error) {
+	if isReservedTopic(pk.TopicName) {
+		return pk, nil
+	}
+
 	log.Printf("[MQTT] Processing publish from client %s on topic: %s", cl.ID, pk.TopicName)
 
 	sensorsData, err := getSensorDataFromTopic(pk.TopicName)
@@ -44,64 +111,79 @@ func (h *DataHook) OnPublish(cl *mqtt.Client, pk packets.Packet) (packets.Packet
 	log.Printf("[MQTT] Extracted sensor data - Section: %s, Module: %s, Sensor: %s",
 		sensorsData.Section, sensorsData.Module, sensorsData.Sensor)
 
-	sensor, err := h.db.GetSensorByNameAndModuleAndSection(
-		sensorsData.Sensor,
-		sensorsData.Module,
-		sensorsData.Section,
-		time.Now(),
-		time.Now(),
-	)
+	sensorID, err := h.resolveSensorID(sensorsData)
 	if err != nil {
-		log.Printf("[MQTT] Error retrieving sensor from database - Sensor: %s, Module: %s, Section: %s, Error: %v",
+		log.Printf("[MQTT] Error resolving sensor - Sensor: %s, Module: %s, Section: %s, Error: %v",
 			sensorsData.Sensor, sensorsData.Module, sensorsData.Section, err)
 		return pk, err
 	}
 
-	log.Printf("[MQTT] Found sensor in database - ID: %d, Name: %s", sensor.ID, sensor.Name)
+	log.Printf("[MQTT] Resolved sensor - ID: %d, Name: %s", sensorID, sensorsData.Sensor)
 
-	if len(pk.Payload) != 8 {
-		log.Printf("[MQTT] Invalid payload length: %d bytes (expected 8) for topic: %s", len(pk.Payload), pk.TopicName)
-		return pk, fmt.Errorf("invalid payload length: %d", len(pk.Payload))
+	decoder := h.decoderFor(pk.TopicName)
+
+	samples, err := decodePayload(pk.Payload, decoder)
+	if err != nil {
+		log.Printf("[MQTT] Error decoding payload of %d bytes for topic %s: %v", len(pk.Payload), pk.TopicName, err)
+		return pk, err
 	}
 
-	log.Printf("[MQTT] Processing payload of %d bytes for sensor %s", len(pk.Payload), sensor.Name)
+	log.Printf("[MQTT] Decoded %d %s samples for sensor %s", len(samples), decoder.Type(), sensorsData.Sensor)
+
+	for _, sample := range samples {
+		record := &db.Record{
+			SensorID:  sensorID,
+			Value:     sample.Value,
+			Type:      decoder.Type(),
+			State:     decoder.State(sample.Value),
+			CreatedAt: sample.CreatedAt,
+		}
+
+		err = h.publishSample(sensorsData, record)
+		if err != nil {
+			log.Printf("[MQTT] Error publishing sample - SensorID: %d, Value: %f, Error: %v",
+				record.SensorID, record.Value, err)
+			return pk, err
+		}
+	}
 
-	timestampBytes := pk.Payload[:4]
-	timestamp := binary.BigEndian.Uint32(timestampBytes)
-	time := time.Unix(int64(timestamp), 0)
+	log.Printf("[MQTT] Successfully published %d samples - SensorID: %d", len(samples), sensorID)
 
-	log.Printf("[MQTT] Extracted timestamp: %s (Unix: %d)", time.Format("2006-01-02T15:04:05Z07:00"), timestamp)
+	return pk, nil
+}
 
-	valueBytes := pk.Payload[4:]
-	var value float32
-	err = binary.Read(bytes.NewReader(valueBytes), binary.LittleEndian, &value)
-	if err != nil {
-		log.Printf("[MQTT] Error reading value from payload bytes %v: %v", valueBytes, err)
-		return pk, err
+func (h *DataHook) decoderFor(topic string) Decoder {
+	if decoder, ok := (*h.decoders.Load())[topic]; ok {
+		return decoder
 	}
 
-	log.Printf("[MQTT] Extracted value: %f for sensor %s", value, sensor.Name)
+	return defaultDecoder
+}
+
+func (h *DataHook) resolveSensorID(sensorData *SensorData) (uint, error) {
+	if h.cache != nil {
+		id, ok := h.cache.Lookup(sensorData.Section, sensorData.Module, sensorData.Sensor)
+		if !ok {
+			return 0, errors.New("sensor not found")
+		}
 
-	record := &db.Record{
-		SensorID:  sensor.ID,
-		Value:     value,
-		CreatedAt: time,
+		return id, nil
 	}
 
-	log.Printf("[MQTT] Creating record - SensorID: %d, Value: %f, Timestamp: %s",
-		record.SensorID, record.Value, record.CreatedAt.Format("2006-01-02T15:04:05Z07:00"))
+	return h.db.GetSensorIDByNameAndModuleAndSection(sensorData.Sensor, sensorData.Module, sensorData.Section)
+}
 
-	err = h.db.InsertRecord(record)
-	if err != nil {
-		log.Printf("[MQTT] Error inserting record into database - SensorID: %d, Value: %f, Error: %v",
-			record.SensorID, record.Value, err)
-		return pk, err
+func (h *DataHook) publishSample(sensorData *SensorData, record *db.Record) error {
+	if h.bus == nil {
+		return nil
 	}
 
-	log.Printf("[MQTT] Successfully inserted record - SensorID: %d, Value: %f, Timestamp: %s",
-		record.SensorID, record.Value, record.CreatedAt.Format("2006-01-02T15:04:05Z07:00"))
-
-	return pk, nil
+	return h.bus.Publish(bus.Sample{
+		Section: sensorData.Section,
+		Module:  sensorData.Module,
+		Sensor:  sensorData.Sensor,
+		Record:  *record,
+	})
 }
 
 func getSensorDataFromTopic(topic string) (*SensorData, error) {
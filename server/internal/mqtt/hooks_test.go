@@ -2,7 +2,13 @@ package mqtt
 
 import (
 	"testing"
+	"time"
 
+	"github.com/ApexCorse/ephoros/server/internal/bus"
+	"github.com/ApexCorse/ephoros/server/internal/config"
+	"github.com/ApexCorse/ephoros/server/internal/db"
+	mqtt "github.com/mochi-mqtt/server/v2"
+	"github.com/mochi-mqtt/server/v2/packets"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -120,3 +126,88 @@ func TestGetSensorDataFromTopic(t *testing.T) {
 		}
 	}
 }
+
+func TestDataHook_IgnoresReservedTopics(t *testing.T) {
+	hook := NewDataHook(&DataHookConfig{})
+
+	pk := packets.Packet{TopicName: "ephoros/alerts/cell-overtemp", Payload: []byte(`{"state":"firing"}`)}
+	out, err := hook.OnPublish(&mqtt.Client{ID: "inline"}, pk)
+	assert.NoError(t, err)
+	assert.Equal(t, pk, out)
+}
+
+func TestDataHook_PublishesSamples(t *testing.T) {
+	gormDb, cleanUp, err := db.TestDB()
+	if err != nil {
+		t.Fatal("cannot setup db")
+	}
+	defer cleanUp()
+
+	database := db.NewDB(gormDb)
+
+	section := &db.Section{Name: "Battery"}
+	gormDb.Create(section)
+	module := &db.Module{Name: "Pack", SectionID: section.ID}
+	gormDb.Create(module)
+	sensor := &db.Sensor{Name: "Current", ModuleID: module.ID}
+	gormDb.Create(sensor)
+
+	cache := db.NewSensorCache(database)
+	assert.Nil(t, cache.Refresh())
+
+	eventBus := bus.New()
+	defer eventBus.Close()
+
+	received := make(chan bus.Sample, 4)
+	_, err = eventBus.Subscribe(&bus.SubscriberConfig{Name: "test"}, func(sample bus.Sample) {
+		received <- sample
+	})
+	assert.Nil(t, err)
+
+	hook := NewDataHook(&DataHookConfig{
+		Config: &config.Config{SensorConfigs: []config.SensorConfig{
+			{Name: "Current", ID: 1, Section: "Battery", Module: "Pack", Encoding: "int16"},
+		}},
+		DB:    database,
+		Cache: cache,
+		Bus:   eventBus,
+	})
+
+	payload := []byte{
+		frameMagic, frameVersion1, frameResolutionMillis, 0x00, 0x02,
+		0x00, 0x00, 0x01, 0x8f, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x18, 0xfc,
+		0x00, 0x00, 0x00, 0x0a, 0xe8, 0x03,
+	}
+	_, err = hook.OnPublish(&mqtt.Client{ID: "ecu"}, packets.Packet{TopicName: "Battery/Pack/Current", Payload: payload})
+	assert.NoError(t, err)
+
+	base := time.UnixMilli(0x018f00000000)
+	for i, expected := range []struct {
+		value     float64
+		createdAt time.Time
+	}{
+		{value: -1000, createdAt: base},
+		{value: 1000, createdAt: base.Add(10 * time.Millisecond)},
+	} {
+		select {
+		case sample := <-received:
+			assert.Equal(t, "Battery/Pack/Current", sample.Path(), i)
+			assert.Equal(t, sensor.ID, sample.Record.SensorID, i)
+			assert.Equal(t, db.TypeInt16, sample.Record.Type, i)
+			assert.Equal(t, expected.value, sample.Record.Value, i)
+			assert.True(t, expected.createdAt.Equal(sample.Record.CreatedAt), i)
+		case <-time.After(time.Second):
+			t.Fatalf("sample %d not delivered", i)
+		}
+	}
+
+	_, err = hook.OnPublish(&mqtt.Client{ID: "ecu"}, packets.Packet{TopicName: "Battery/Pack/Unknown", Payload: payload})
+	assert.Error(t, err)
+
+	select {
+	case sample := <-received:
+		t.Fatalf("unexpected sample for %s", sample.Path())
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -2,7 +2,10 @@ package mqtt
 
 import (
 	"crypto/tls"
+	"fmt"
 	"log"
+	"reflect"
+	"sync/atomic"
 
 	"github.com/ApexCorse/ephoros/server/internal/config"
 	"github.com/ApexCorse/ephoros/server/internal/db"
@@ -26,9 +29,11 @@ type MQTTConfig struct {
 }
 
 type MQTT struct {
-	s      *mqtt.Server
-	config *config.Config
-	db     *db.DB
+	s            *mqtt.Server
+	certificates []tls.Certificate
+	config       atomic.Pointer[config.Config]
+	db           *db.DB
+	auth         *AuthHook
 }
 
 func NewMQTT(cfg *MQTTConfig) *MQTT {
@@ -45,19 +50,8 @@ func NewMQTT(cfg *MQTTConfig) *MQTT {
 		log.Println("[MQTT] config is nil, caution")
 	}
 
-	authRules := auth.AuthRules{}
-	for _, mConfig := range cfg.Config.MQTT {
-		authRules = append(authRules, auth.AuthRule{
-			Username: auth.RString(mConfig.Username),
-			Password: auth.RString(mConfig.Password),
-			Allow:    true,
-		})
-	}
-	s.AddHook(&auth.Hook{}, &auth.Options{
-		Ledger: &auth.Ledger{
-			Auth: authRules,
-		},
-	})
+	authHook := NewAuthHook(cfg.Config)
+	s.AddHook(authHook, nil)
 
 	for _, hook := range cfg.Hooks {
 		s.AddHook(hook.Hook, hook.Options)
@@ -67,9 +61,133 @@ func NewMQTT(cfg *MQTTConfig) *MQTT {
 		s.AddListener(listener)
 	}
 
-	return &MQTT{
-		s:      s,
-		config: cfg.Config,
-		db:     cfg.DB,
+	m := &MQTT{
+		s:            s,
+		certificates: cfg.Certificates,
+		db:           cfg.DB,
+		auth:         authHook,
+	}
+	m.config.Store(cfg.Config)
+
+	return m
+}
+
+func (m *MQTT) SetConfig(cfg *config.Config) {
+	log.Println("[MQTT] Updating broker configuration")
+
+	m.auth.SetConfig(cfg)
+
+	previous := m.config.Swap(cfg)
+	if previous != nil && !reflect.DeepEqual(previous.Broker, cfg.Broker) {
+		log.Println("[MQTT] Broker listener configuration changed, restart required to apply it")
+	}
+}
+
+func buildLedger(cfg *config.Config) *auth.Ledger {
+	aclRules := auth.ACLRules{}
+	for _, mConfig := range cfg.MQTT {
+		if !mConfig.HasACL() {
+			log.Printf("[MQTT] User %s has no ACL, granting full access", mConfig.Username)
+			continue
+		}
+
+		aclRules = append(aclRules, auth.ACLRule{
+			Username: auth.RString(mConfig.Username),
+			Filters:  aclFilters(&mConfig),
+		})
+	}
+
+	return &auth.Ledger{
+		ACL: aclRules,
 	}
 }
+
+func aclFilters(mConfig *config.MQTTUserConfig) auth.Filters {
+	filters := auth.Filters{"#": auth.Deny}
+
+	for _, filter := range mConfig.Publish {
+		filters[auth.RString(filter)] = auth.WriteOnly
+	}
+
+	for _, filter := range mConfig.Subscribe {
+		if filters[auth.RString(filter)] == auth.WriteOnly {
+			filters[auth.RString(filter)] = auth.ReadWrite
+		} else {
+			filters[auth.RString(filter)] = auth.ReadOnly
+		}
+	}
+
+	return filters
+}
+
+func (m *MQTT) Start() error {
+	log.Println("[MQTT] Starting broker")
+
+	if err := m.addConfiguredListeners(); err != nil {
+		log.Printf("[MQTT] Broker failed to start: %v", err)
+		return err
+	}
+
+	if err := m.s.Serve(); err != nil {
+		log.Printf("[MQTT] Broker failed to start: %v", err)
+		return err
+	}
+
+	log.Println("[MQTT] Broker started")
+	return nil
+}
+
+func (m *MQTT) addConfiguredListeners() error {
+	cfg := m.config.Load()
+	if cfg == nil || cfg.Broker == nil {
+		return nil
+	}
+
+	if tlsCfg := cfg.Broker.TLS; tlsCfg != nil {
+		tlsConfig, err := NewTLSConfig(tlsCfg, m.certificates)
+		if err != nil {
+			return err
+		}
+
+		listener := listeners.NewTCP(listeners.Config{
+			ID:        "tls",
+			Address:   tlsCfg.Address,
+			TLSConfig: tlsConfig,
+		})
+		if err := m.s.AddListener(listener); err != nil {
+			return fmt.Errorf("cannot listen for TLS on %s: %w", tlsCfg.Address, err)
+		}
+
+		log.Printf("[MQTT] TLS listener on %s - Client auth: %s", listener.Address(), tlsCfg.ClientAuthMode())
+	}
+
+	if wsCfg := cfg.Broker.WebSocket; wsCfg != nil {
+		listener := listeners.NewWebsocket(listeners.Config{
+			ID:      "ws",
+			Address: wsCfg.Address,
+		})
+		if err := m.s.AddListener(listener); err != nil {
+			return fmt.Errorf("cannot listen for WebSocket connections on %s: %w", wsCfg.Address, err)
+		}
+
+		log.Printf("[MQTT] WebSocket listener on %s", listener.Address())
+	}
+
+	return nil
+}
+
+func (m *MQTT) Publish(topic string, payload []byte, retain bool, qos byte) error {
+	return m.s.Publish(topic, payload, retain, qos)
+}
+
+func (m *MQTT) Close() error {
+	log.Println("[MQTT] Closing broker")
+
+	if err := m.s.Close(); err != nil {
+		log.Printf("[MQTT] Error closing broker: %v", err)
+		return err
+	}
+
+	log.Println("[MQTT] Broker closed")
+	return nil
+}
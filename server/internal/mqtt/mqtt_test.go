@@ -0,0 +1,139 @@
+package mqtt
+
+import (
+	"testing"
+
+	"github.com/ApexCorse/ephoros/server/internal/config"
+	mqtt "github.com/mochi-mqtt/server/v2"
+	"github.com/mochi-mqtt/server/v2/hooks/auth"
+	"github.com/mochi-mqtt/server/v2/packets"
+	"github.com/stretchr/testify/assert"
+)
+
+func authOk(h *AuthHook, username, password string) bool {
+	cl := &mqtt.Client{Properties: mqtt.ClientProperties{Username: []byte(username)}}
+	return h.OnConnectAuthenticate(cl, packets.Packet{
+		Connect: packets.ConnectParams{
+			UsernameFlag: true,
+			PasswordFlag: true,
+			Username:     []byte(username),
+			Password:     []byte(password),
+		},
+	})
+}
+
+func TestMQTT_SetConfig(t *testing.T) {
+	m := NewMQTT(&MQTTConfig{
+		Config: &config.Config{
+			MQTT: []config.MQTTUserConfig{
+				{Username: "car", Password: "secret"},
+			},
+		},
+		Server: mqtt.New(nil),
+	})
+
+	assert.True(t, authOk(m.auth, "car", "secret"))
+	assert.False(t, authOk(m.auth, "pit", "wall"))
+
+	m.SetConfig(&config.Config{
+		MQTT: []config.MQTTUserConfig{
+			{Username: "pit", Password: "wall"},
+		},
+	})
+
+	assert.False(t, authOk(m.auth, "car", "secret"))
+	assert.True(t, authOk(m.auth, "pit", "wall"))
+	assert.Len(t, m.config.Load().MQTT, 1)
+}
+
+func aclOk(ledger *auth.Ledger, username, topic string, write bool) bool {
+	cl := &mqtt.Client{Properties: mqtt.ClientProperties{Username: []byte(username)}}
+	_, ok := ledger.ACLOk(cl, topic, write)
+	return ok
+}
+
+func TestBuildLedger_ACL(t *testing.T) {
+	ledger := buildLedger(&config.Config{
+		MQTT: []config.MQTTUserConfig{
+			{Username: "ecu", Password: "one", Publish: []string{"Battery/#", "Vehicle/+/Speed"}},
+			{Username: "pit", Password: "two", Subscribe: []string{"#"}},
+			{Username: "dash", Password: "three", Publish: []string{"Dash/Buttons/#"}, Subscribe: []string{"Dash/Buttons/#", "Battery/#"}},
+			{Username: "legacy", Password: "four"},
+		},
+	})
+
+	tests := []struct {
+		username string
+		topic    string
+		write    bool
+		allowed  bool
+	}{
+		{username: "ecu", topic: "Battery/Module 1/NTC-1", write: true, allowed: true},
+		{username: "ecu", topic: "Vehicle/ECU/Speed", write: true, allowed: true},
+		{username: "ecu", topic: "Vehicle/ECU/Throttle", write: true, allowed: false},
+		{username: "ecu", topic: "Engine/Thermal/Oil", write: true, allowed: false},
+		{username: "ecu", topic: "Battery/#", write: false, allowed: false},
+		{username: "ecu", topic: "Battery/Module 1/NTC-1", write: false, allowed: false},
+
+		{username: "pit", topic: "#", write: false, allowed: true},
+		{username: "pit", topic: "Battery/+/NTC-1", write: false, allowed: true},
+		{username: "pit", topic: "Battery/Module 1/NTC-1", write: true, allowed: false},
+
+		{username: "dash", topic: "Dash/Buttons/Reset", write: true, allowed: true},
+		{username: "dash", topic: "Dash/Buttons/#", write: false, allowed: true},
+		{username: "dash", topic: "Battery/Module 1/NTC-1", write: false, allowed: true},
+		{username: "dash", topic: "Battery/Module 1/NTC-1", write: true, allowed: false},
+		{username: "dash", topic: "#", write: false, allowed: false},
+
+		{username: "legacy", topic: "Battery/Module 1/NTC-1", write: true, allowed: true},
+		{username: "legacy", topic: "#", write: false, allowed: true},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.allowed, aclOk(ledger, tt.username, tt.topic, tt.write),
+			"%s write=%t %s", tt.username, tt.write, tt.topic)
+	}
+}
+
+func TestMQTT_SetConfigACL(t *testing.T) {
+	m := NewMQTT(&MQTTConfig{
+		Config: &config.Config{
+			MQTT: []config.MQTTUserConfig{
+				{Username: "ecu", Password: "one"},
+			},
+		},
+		Server: mqtt.New(nil),
+	})
+
+	assert.True(t, aclOk(m.auth.ledger, "ecu", "Engine/Thermal/Oil", true))
+
+	m.SetConfig(&config.Config{
+		MQTT: []config.MQTTUserConfig{
+			{Username: "ecu", Password: "one", Publish: []string{"Battery/#"}},
+		},
+	})
+
+	assert.False(t, aclOk(m.auth.ledger, "ecu", "Engine/Thermal/Oil", true))
+	assert.True(t, aclOk(m.auth.ledger, "ecu", "Battery/Module 1/NTC-1", true))
+}
+
+func TestMQTT_Publish(t *testing.T) {
+	m := NewMQTT(&MQTTConfig{
+		Config: &config.Config{},
+		Hooks:  []HookConfig{{Hook: NewDataHook(&DataHookConfig{})}},
+		Server: mqtt.New(&mqtt.Options{InlineClient: true}),
+	})
+	assert.NoError(t, m.Start())
+	defer m.Close()
+
+	received := make(chan []byte, 1)
+	assert.NoError(t, m.s.Subscribe("ephoros/alerts/#", 1, func(cl *mqtt.Client, sub packets.Subscription, pk packets.Packet) {
+		received <- pk.Payload
+	}))
+
+	assert.NoError(t, m.Publish("ephoros/alerts/cell-overtemp", []byte(`{"state":"firing"}`), false, 1))
+	assert.Equal(t, []byte(`{"state":"firing"}`), <-received)
+
+	disabled := NewMQTT(&MQTTConfig{Config: &config.Config{}, Server: mqtt.New(nil)})
+	assert.ErrorIs(t, disabled.Publish("ephoros/alerts/cell-overtemp", nil, false, 0), mqtt.ErrInlineClientNotEnabled)
+}
@@ -0,0 +1,125 @@
+package mqtt
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+)
+
+// Legacy payload:
+//
+//	uint32 BE seconds | LE value
+//
+// Frame payload, version 1:
+//
+//	magic (0xEF) | version (0x01) | flags | uint16 BE count | uint64 BE base timestamp
+//	count x (uint32 BE delta | LE value)
+//
+// Values are encoded as the sensor's decoder expects, float32 by default.
+// The two low bits of flags select the unit of the base timestamp and of the
+// deltas: 0 for milliseconds, 1 for microseconds.
+const (
+	legacyTimestampLength = 4
+
+	frameMagic        byte = 0xEF
+	frameVersion1     byte = 0x01
+	frameHeaderLength      = 13
+	frameDeltaLength       = 4
+
+	frameResolutionMask   byte = 0x03
+	frameResolutionMillis byte = 0x00
+	frameResolutionMicros byte = 0x01
+)
+
+type Sample struct {
+	CreatedAt time.Time
+	Value     float64
+}
+
+func decodePayload(payload []byte, decoder Decoder) ([]Sample, error) {
+	if len(payload) == legacyTimestampLength+decoder.Size() {
+		sample, err := decodeLegacyPayload(payload, decoder)
+		if err != nil {
+			return nil, err
+		}
+
+		return []Sample{*sample}, nil
+	}
+
+	if len(payload) > 0 && payload[0] == frameMagic {
+		return decodeFrame(payload, decoder)
+	}
+
+	return nil, fmt.Errorf("invalid payload length: %d", len(payload))
+}
+
+func decodeLegacyPayload(payload []byte, decoder Decoder) (*Sample, error) {
+	if len(payload) != legacyTimestampLength+decoder.Size() {
+		return nil, fmt.Errorf("invalid payload length: %d", len(payload))
+	}
+
+	timestamp := binary.BigEndian.Uint32(payload[:legacyTimestampLength])
+
+	return &Sample{
+		CreatedAt: time.Unix(int64(timestamp), 0),
+		Value:     decoder.Decode(payload[legacyTimestampLength:]),
+	}, nil
+}
+
+func decodeFrame(payload []byte, decoder Decoder) ([]Sample, error) {
+	if len(payload) < frameHeaderLength {
+		return nil, fmt.Errorf("frame too short: %d bytes", len(payload))
+	}
+
+	if payload[0] != frameMagic {
+		return nil, fmt.Errorf("invalid frame magic: 0x%02x", payload[0])
+	}
+
+	if payload[1] != frameVersion1 {
+		return nil, fmt.Errorf("unsupported frame version: %d", payload[1])
+	}
+
+	flags := payload[2]
+	if flags&^frameResolutionMask != 0 {
+		return nil, fmt.Errorf("unsupported frame flags: 0x%02x", flags)
+	}
+
+	base := int64(binary.BigEndian.Uint64(payload[5:13]))
+
+	var unit time.Duration
+	var baseTime time.Time
+	switch flags & frameResolutionMask {
+	case frameResolutionMillis:
+		unit = time.Millisecond
+		baseTime = time.UnixMilli(base)
+	case frameResolutionMicros:
+		unit = time.Microsecond
+		baseTime = time.UnixMicro(base)
+	default:
+		return nil, fmt.Errorf("unsupported frame resolution: %d", flags&frameResolutionMask)
+	}
+
+	count := int(binary.BigEndian.Uint16(payload[3:5]))
+	if count == 0 {
+		return nil, fmt.Errorf("empty frame")
+	}
+
+	sampleLength := frameDeltaLength + decoder.Size()
+	expected := frameHeaderLength + count*sampleLength
+	if len(payload) != expected {
+		return nil, fmt.Errorf("invalid frame length: %d bytes (expected %d for %d samples)", len(payload), expected, count)
+	}
+
+	samples := make([]Sample, count)
+	for i := range samples {
+		offset := frameHeaderLength + i*sampleLength
+		delta := binary.BigEndian.Uint32(payload[offset : offset+frameDeltaLength])
+
+		samples[i] = Sample{
+			CreatedAt: baseTime.Add(time.Duration(delta) * unit),
+			Value:     decoder.Decode(payload[offset+frameDeltaLength : offset+sampleLength]),
+		}
+	}
+
+	return samples, nil
+}
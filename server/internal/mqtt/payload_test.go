@@ -0,0 +1,149 @@
+package mqtt
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/ApexCorse/ephoros/server/internal/config"
+	"github.com/ApexCorse/ephoros/server/internal/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func legacyPayload(timestamp uint32, value float32) []byte {
+	payload := make([]byte, 8)
+	binary.BigEndian.PutUint32(payload[:4], timestamp)
+	binary.LittleEndian.PutUint32(payload[4:], math.Float32bits(value))
+	return payload
+}
+
+func framePayload(flags byte, base uint64, deltas []uint32, values []float32) []byte {
+	payload := []byte{frameMagic, frameVersion1, flags}
+	payload = binary.BigEndian.AppendUint16(payload, uint16(len(deltas)))
+	payload = binary.BigEndian.AppendUint64(payload, base)
+	for i := range deltas {
+		payload = binary.BigEndian.AppendUint32(payload, deltas[i])
+		payload = binary.LittleEndian.AppendUint32(payload, math.Float32bits(values[i]))
+	}
+	return payload
+}
+
+func TestDecodePayload(t *testing.T) {
+	tests := []struct {
+		name        string
+		payload     []byte
+		decoder     Decoder
+		expected    []Sample
+		expectError bool
+	}{
+		{
+			name:    "legacy",
+			payload: legacyPayload(1700000000, 42.5),
+			expected: []Sample{
+				{CreatedAt: time.Unix(1700000000, 0), Value: 42.5},
+			},
+		},
+		{
+			name:    "frame milliseconds",
+			payload: framePayload(frameResolutionMillis, 1700000000123, []uint32{0, 10, 25}, []float32{1, 2, 3}),
+			expected: []Sample{
+				{CreatedAt: time.UnixMilli(1700000000123), Value: 1},
+				{CreatedAt: time.UnixMilli(1700000000133), Value: 2},
+				{CreatedAt: time.UnixMilli(1700000000148), Value: 3},
+			},
+		},
+		{
+			name:    "frame microseconds",
+			payload: framePayload(frameResolutionMicros, 1700000000123456, []uint32{0, 500}, []float32{-1.5, 7}),
+			expected: []Sample{
+				{CreatedAt: time.UnixMicro(1700000000123456), Value: -1.5},
+				{CreatedAt: time.UnixMicro(1700000000123956), Value: 7},
+			},
+		},
+		{
+			name:    "legacy typed",
+			payload: []byte{0x65, 0x53, 0xf1, 0x00, 0xff, 0xff},
+			decoder: decoders[db.TypeInt16](&config.SensorConfig{}),
+			expected: []Sample{
+				{CreatedAt: time.Unix(1700000000, 0), Value: -1},
+			},
+		},
+		{
+			name: "frame typed",
+			payload: []byte{
+				frameMagic, frameVersion1, frameResolutionMillis, 0x00, 0x02,
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x03, 0xe8,
+				0x00, 0x00, 0x00, 0x00, 0x01,
+				0x00, 0x00, 0x00, 0x05, 0x00,
+			},
+			decoder: decoders[db.TypeBool](&config.SensorConfig{}),
+			expected: []Sample{
+				{CreatedAt: time.UnixMilli(1000), Value: 1},
+				{CreatedAt: time.UnixMilli(1005), Value: 0},
+			},
+		},
+		{
+			name:        "empty",
+			payload:     []byte{},
+			expectError: true,
+		},
+		{
+			name:        "wrong legacy length",
+			payload:     []byte{0, 1, 2, 3, 4, 5, 6},
+			expectError: true,
+		},
+		{
+			name:        "unsupported version",
+			payload:     append([]byte{frameMagic, 0x02}, framePayload(frameResolutionMillis, 0, []uint32{0}, []float32{1})[2:]...),
+			expectError: true,
+		},
+		{
+			name:        "unsupported resolution",
+			payload:     framePayload(0x02, 0, []uint32{0}, []float32{1}),
+			expectError: true,
+		},
+		{
+			name:        "reserved flags",
+			payload:     framePayload(0x80, 0, []uint32{0}, []float32{1}),
+			expectError: true,
+		},
+		{
+			name:        "no samples",
+			payload:     framePayload(frameResolutionMillis, 0, []uint32{}, []float32{}),
+			expectError: true,
+		},
+		{
+			name:        "truncated samples",
+			payload:     framePayload(frameResolutionMillis, 0, []uint32{0, 1}, []float32{1, 2})[:25],
+			expectError: true,
+		},
+		{
+			name:        "truncated header",
+			payload:     framePayload(frameResolutionMillis, 0, []uint32{0}, []float32{1})[:10],
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		decoder := tt.decoder
+		if decoder == nil {
+			decoder = defaultDecoder
+		}
+
+		samples, err := decodePayload(tt.payload, decoder)
+
+		if tt.expectError {
+			assert.Error(t, err, tt.name)
+			assert.Nil(t, samples, tt.name)
+			continue
+		}
+
+		assert.NoError(t, err, tt.name)
+		assert.Len(t, samples, len(tt.expected), tt.name)
+		for i := range tt.expected {
+			assert.True(t, tt.expected[i].CreatedAt.Equal(samples[i].CreatedAt), tt.name)
+			assert.Equal(t, tt.expected[i].Value, samples[i].Value, tt.name)
+		}
+	}
+}
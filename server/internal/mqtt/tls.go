@@ -0,0 +1,74 @@
+package mqtt
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/ApexCorse/ephoros/server/internal/config"
+	mqtt "github.com/mochi-mqtt/server/v2"
+)
+
+func NewTLSConfig(cfg *config.TLSConfig, certificates []tls.Certificate) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: certificates,
+	}
+
+	if len(tlsConfig.Certificates) == 0 {
+		certificate, err := tls.LoadX509KeyPair(cfg.Cert, cfg.Key)
+		if err != nil {
+			return nil, fmt.Errorf("cannot load TLS certificate: %w", err)
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	if cfg.CA != "" {
+		pem, err := os.ReadFile(cfg.CA)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read client CA: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in client CA %s", cfg.CA)
+		}
+
+		tlsConfig.ClientCAs = pool
+	}
+
+	switch cfg.ClientAuthMode() {
+	case config.ClientAuthNone:
+		tlsConfig.ClientAuth = tls.NoClientCert
+	case config.ClientAuthRequest:
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	case config.ClientAuthRequire:
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	default:
+		return nil, fmt.Errorf("unknown client auth mode: %q", cfg.ClientAuth)
+	}
+
+	if tlsConfig.ClientAuth != tls.NoClientCert && tlsConfig.ClientCAs == nil {
+		return nil, errors.New("client certificate verification requires a CA")
+	}
+
+	return tlsConfig, nil
+}
+
+func certificateUsername(cl *mqtt.Client) (string, bool) {
+	conn, ok := cl.Net.Conn.(*tls.Conn)
+	if !ok {
+		return "", false
+	}
+
+	state := conn.ConnectionState()
+	if len(state.VerifiedChains) == 0 || len(state.PeerCertificates) == 0 {
+		return "", false
+	}
+
+	username := state.PeerCertificates[0].Subject.CommonName
+	return username, username != ""
+}
@@ -0,0 +1,247 @@
+package mqtt
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ApexCorse/ephoros/server/internal/config"
+	mqtt "github.com/mochi-mqtt/server/v2"
+	"github.com/mochi-mqtt/server/v2/packets"
+	"github.com/stretchr/testify/assert"
+)
+
+type testCertificate struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+	keyPEM  []byte
+}
+
+func newTestCertificate(t *testing.T, commonName string, parent *testCertificate) *testCertificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal("cannot generate key")
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+
+	signer, signerKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal("cannot create certificate")
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal("cannot parse certificate")
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal("cannot marshal key")
+	}
+
+	return &testCertificate{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+func (c *testCertificate) tlsCertificate(t *testing.T) tls.Certificate {
+	certificate, err := tls.X509KeyPair(c.certPEM, c.keyPEM)
+	if err != nil {
+		t.Fatal("cannot load key pair")
+	}
+
+	return certificate
+}
+
+func writeTestFile(t *testing.T, dir, name string, content []byte) string {
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, content, 0o600); err != nil {
+		t.Fatal("cannot write file")
+	}
+
+	return path
+}
+
+func encodeConnect(t *testing.T, username, password string) []byte {
+	pk := packets.Packet{
+		FixedHeader:     packets.FixedHeader{Type: packets.Connect},
+		ProtocolVersion: 4,
+		Connect: packets.ConnectParams{
+			ProtocolName:     []byte("MQTT"),
+			Clean:            true,
+			Keepalive:        30,
+			ClientIdentifier: "tls-test",
+			UsernameFlag:     username != "",
+			Username:         []byte(username),
+			PasswordFlag:     password != "",
+			Password:         []byte(password),
+		},
+	}
+
+	buf := &bytes.Buffer{}
+	if err := pk.ConnectEncode(buf); err != nil {
+		t.Fatal("cannot encode connect packet")
+	}
+
+	return buf.Bytes()
+}
+
+func connectTLS(t *testing.T, address string, tlsConfig *tls.Config, username, password string) (byte, error) {
+	conn, err := tls.Dial("tcp", address, tlsConfig)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	if _, err := conn.Write(encodeConnect(t, username, password)); err != nil {
+		return 0, err
+	}
+
+	connack := make([]byte, 4)
+	if _, err := io.ReadFull(conn, connack); err != nil {
+		return 0, err
+	}
+
+	return connack[3], nil
+}
+
+func TestMQTT_TLSListener(t *testing.T) {
+	ca := newTestCertificate(t, "Ephoros Test CA", nil)
+	server := newTestCertificate(t, "broker", ca)
+	logger := newTestCertificate(t, "logger", ca)
+	stranger := newTestCertificate(t, "stranger", ca)
+	rogueCA := newTestCertificate(t, "Rogue CA", nil)
+	rogue := newTestCertificate(t, "logger", rogueCA)
+
+	dir := t.TempDir()
+	cfg := &config.Config{
+		Broker: &config.BrokerConfig{
+			TLS: &config.TLSConfig{
+				Address:    "127.0.0.1:0",
+				Cert:       writeTestFile(t, dir, "server.pem", server.certPEM),
+				Key:        writeTestFile(t, dir, "server.key", server.keyPEM),
+				CA:         writeTestFile(t, dir, "ca.pem", ca.certPEM),
+				ClientAuth: config.ClientAuthRequest,
+			},
+		},
+		MQTT: []config.MQTTUserConfig{
+			{Username: "car", Password: "secret"},
+			{Username: "logger", Password: "unused"},
+		},
+	}
+
+	m := NewMQTT(&MQTTConfig{Config: cfg, Server: mqtt.New(nil)})
+	assert.NoError(t, m.Start())
+	defer m.Close()
+
+	listener, ok := m.s.Listeners.Get("tls")
+	assert.True(t, ok)
+	address := listener.Address()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+
+	tests := []struct {
+		name        string
+		certificate *testCertificate
+		username    string
+		password    string
+		accepted    bool
+	}{
+		{name: "password", username: "car", password: "secret", accepted: true},
+		{name: "wrong password", username: "car", password: "wrong", accepted: false},
+		{name: "client certificate", certificate: logger, accepted: true},
+		{name: "client certificate overrides username", certificate: logger, username: "car", password: "wrong", accepted: true},
+		{name: "certificate for unknown user", certificate: stranger, username: "car", password: "secret", accepted: false},
+	}
+
+	for _, tt := range tests {
+		tlsConfig := &tls.Config{RootCAs: roots}
+		if tt.certificate != nil {
+			tlsConfig.Certificates = []tls.Certificate{tt.certificate.tlsCertificate(t)}
+		}
+
+		code, err := connectTLS(t, address, tlsConfig, tt.username, tt.password)
+		assert.NoError(t, err, tt.name)
+		assert.Equal(t, tt.accepted, code == packets.CodeSuccess.Code, tt.name)
+	}
+
+	rogueCertificate := rogue.tlsCertificate(t)
+	_, err := connectTLS(t, address, &tls.Config{
+		RootCAs: roots,
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return &rogueCertificate, nil
+		},
+	}, "", "")
+	assert.Error(t, err, "certificates from an unknown CA must be rejected")
+
+	_, err = connectTLS(t, address, &tls.Config{}, "car", "secret")
+	assert.Error(t, err, "the server certificate must not verify without the CA")
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	ca := newTestCertificate(t, "Ephoros Test CA", nil)
+	server := newTestCertificate(t, "broker", ca)
+
+	dir := t.TempDir()
+	certPath := writeTestFile(t, dir, "server.pem", server.certPEM)
+	keyPath := writeTestFile(t, dir, "server.key", server.keyPEM)
+	caPath := writeTestFile(t, dir, "ca.pem", ca.certPEM)
+
+	tlsConfig, err := NewTLSConfig(&config.TLSConfig{Cert: certPath, Key: keyPath}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, tls.NoClientCert, tlsConfig.ClientAuth)
+	assert.Len(t, tlsConfig.Certificates, 1)
+
+	tlsConfig, err = NewTLSConfig(&config.TLSConfig{Cert: certPath, Key: keyPath, CA: caPath}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, tls.RequireAndVerifyClientCert, tlsConfig.ClientAuth)
+
+	tlsConfig, err = NewTLSConfig(&config.TLSConfig{CA: caPath, ClientAuth: config.ClientAuthNone},
+		[]tls.Certificate{server.tlsCertificate(t)})
+	assert.NoError(t, err)
+	assert.Equal(t, tls.NoClientCert, tlsConfig.ClientAuth)
+
+	_, err = NewTLSConfig(&config.TLSConfig{Cert: filepath.Join(dir, "missing.pem"), Key: keyPath}, nil)
+	assert.Error(t, err)
+
+	_, err = NewTLSConfig(&config.TLSConfig{Cert: certPath, Key: keyPath, CA: keyPath}, nil)
+	assert.Error(t, err)
+
+	_, err = NewTLSConfig(&config.TLSConfig{Cert: certPath, Key: keyPath, ClientAuth: config.ClientAuthRequire}, nil)
+	assert.Error(t, err)
+}
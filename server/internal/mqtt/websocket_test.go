@@ -0,0 +1,100 @@
+package mqtt
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ApexCorse/ephoros/server/internal/config"
+	"github.com/gorilla/websocket"
+	mqtt "github.com/mochi-mqtt/server/v2"
+	"github.com/mochi-mqtt/server/v2/packets"
+	"github.com/stretchr/testify/assert"
+)
+
+func freeAddress(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("cannot reserve address")
+	}
+	defer l.Close()
+
+	return l.Addr().String()
+}
+
+func dialWebSocket(t *testing.T, address string) *websocket.Conn {
+	dialer := websocket.Dialer{Subprotocols: []string{"mqtt"}}
+
+	var lastErr error
+	for range 50 {
+		conn, _, err := dialer.Dial("ws://"+address+"/", nil)
+		if err == nil {
+			return conn
+		}
+		lastErr = err
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	t.Fatalf("cannot dial websocket listener: %v", lastErr)
+	return nil
+}
+
+func readWebSocketPacket(t *testing.T, conn *websocket.Conn) []byte {
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, message, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("cannot read websocket message: %v", err)
+	}
+
+	return message
+}
+
+func TestMQTT_WebSocketListener(t *testing.T) {
+	address := freeAddress(t)
+	m := NewMQTT(&MQTTConfig{
+		Config: &config.Config{
+			Broker: &config.BrokerConfig{
+				WebSocket: &config.WebSocketConfig{Address: address},
+			},
+			MQTT: []config.MQTTUserConfig{
+				{Username: "dashboard", Password: "secret", Subscribe: []string{"sensors/#"}},
+			},
+		},
+		Server: mqtt.New(nil),
+	})
+	assert.NoError(t, m.Start())
+	defer m.Close()
+
+	rejected := dialWebSocket(t, address)
+	defer rejected.Close()
+
+	assert.NoError(t, rejected.WriteMessage(websocket.BinaryMessage, encodeConnect(t, "dashboard", "wrong")))
+	connack := readWebSocketPacket(t, rejected)
+	assert.Len(t, connack, 4)
+	assert.NotEqual(t, packets.CodeSuccess.Code, connack[3])
+
+	conn := dialWebSocket(t, address)
+	defer conn.Close()
+
+	assert.NoError(t, conn.WriteMessage(websocket.BinaryMessage, encodeConnect(t, "dashboard", "secret")))
+	connack = readWebSocketPacket(t, conn)
+	assert.Len(t, connack, 4)
+	assert.Equal(t, packets.CodeSuccess.Code, connack[3])
+
+	subscribe := packets.Packet{
+		FixedHeader:     packets.FixedHeader{Type: packets.Subscribe, Qos: 1},
+		ProtocolVersion: 4,
+		PacketID:        1,
+		Filters: packets.Subscriptions{
+			{Filter: "sensors/Battery/Pack/Current"},
+			{Filter: "ephoros/secret"},
+		},
+	}
+	buf := &bytes.Buffer{}
+	assert.NoError(t, subscribe.SubscribeEncode(buf))
+	assert.NoError(t, conn.WriteMessage(websocket.BinaryMessage, buf.Bytes()))
+
+	suback := readWebSocketPacket(t, conn)
+	assert.Equal(t, []byte{packets.CodeSuccess.Code, packets.ErrUnspecifiedError.Code}, suback[len(suback)-2:])
+}
@@ -0,0 +1,189 @@
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type Algorithm string
+
+const (
+	AlgorithmBcrypt   Algorithm = "bcrypt"
+	AlgorithmArgon2id Algorithm = "argon2id"
+)
+
+const (
+	argon2idPrefix  = "$argon2id$"
+	argon2idMemory  = 19 * 1024
+	argon2idTime    = 2
+	argon2idThreads = 1
+	argon2idSaltLen = 16
+	argon2idKeyLen  = 32
+)
+
+var ErrMismatch = errors.New("password does not match")
+
+type Verifier interface {
+	Verify(password string) error
+}
+
+func Hash(password string, algorithm Algorithm, cost int) (string, error) {
+	if password == "" {
+		return "", errors.New("empty password")
+	}
+
+	switch algorithm {
+	case AlgorithmBcrypt:
+		if cost == 0 {
+			cost = bcrypt.DefaultCost
+		}
+
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), cost)
+		if err != nil {
+			return "", err
+		}
+
+		return string(hash), nil
+	case AlgorithmArgon2id:
+		if cost == 0 {
+			cost = argon2idTime
+		}
+
+		salt := make([]byte, argon2idSaltLen)
+		if _, err := rand.Read(salt); err != nil {
+			return "", err
+		}
+
+		v := &argon2idVerifier{
+			memory:  argon2idMemory,
+			time:    uint32(cost),
+			threads: argon2idThreads,
+			salt:    salt,
+		}
+		v.key = v.derive(password, argon2idKeyLen)
+
+		return v.String(), nil
+	default:
+		return "", fmt.Errorf("unknown password hash algorithm: %q", algorithm)
+	}
+}
+
+func IsHash(encoded string) bool {
+	return isBcrypt(encoded) || strings.HasPrefix(encoded, argon2idPrefix)
+}
+
+func Parse(encoded string) (Verifier, error) {
+	switch {
+	case isBcrypt(encoded):
+		if _, err := bcrypt.Cost([]byte(encoded)); err != nil {
+			return nil, fmt.Errorf("invalid bcrypt hash: %w", err)
+		}
+		return bcryptVerifier(encoded), nil
+	case strings.HasPrefix(encoded, argon2idPrefix):
+		return parseArgon2id(encoded)
+	default:
+		return plainVerifier(encoded), nil
+	}
+}
+
+func Verify(encoded, password string) error {
+	v, err := Parse(encoded)
+	if err != nil {
+		return err
+	}
+
+	return v.Verify(password)
+}
+
+func isBcrypt(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") || strings.HasPrefix(encoded, "$2b$") || strings.HasPrefix(encoded, "$2y$")
+}
+
+type plainVerifier string
+
+func (v plainVerifier) Verify(password string) error {
+	if subtle.ConstantTimeCompare([]byte(v), []byte(password)) != 1 {
+		return ErrMismatch
+	}
+
+	return nil
+}
+
+type bcryptVerifier string
+
+func (v bcryptVerifier) Verify(password string) error {
+	if err := bcrypt.CompareHashAndPassword([]byte(v), []byte(password)); err != nil {
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return ErrMismatch
+		}
+		return err
+	}
+
+	return nil
+}
+
+type argon2idVerifier struct {
+	memory  uint32
+	time    uint32
+	threads uint8
+	salt    []byte
+	key     []byte
+}
+
+func parseArgon2id(encoded string) (*argon2idVerifier, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 {
+		return nil, errors.New("invalid argon2id hash: expected $argon2id$v=19$m=...,t=...,p=...$salt$key")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, fmt.Errorf("invalid argon2id hash: unsupported version %q", parts[2])
+	}
+
+	v := &argon2idVerifier{}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &v.memory, &v.time, &v.threads); err != nil {
+		return nil, fmt.Errorf("invalid argon2id hash: invalid parameters %q", parts[3])
+	}
+
+	if v.memory == 0 || v.time == 0 || v.threads == 0 {
+		return nil, fmt.Errorf("invalid argon2id hash: invalid parameters %q", parts[3])
+	}
+
+	var err error
+	v.salt, err = base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil || len(v.salt) == 0 {
+		return nil, errors.New("invalid argon2id hash: invalid salt")
+	}
+
+	v.key, err = base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(v.key) == 0 {
+		return nil, errors.New("invalid argon2id hash: invalid key")
+	}
+
+	return v, nil
+}
+
+func (v *argon2idVerifier) derive(password string, keyLen int) []byte {
+	return argon2.IDKey([]byte(password), v.salt, v.time, v.memory, v.threads, uint32(keyLen))
+}
+
+func (v *argon2idVerifier) Verify(password string) error {
+	if subtle.ConstantTimeCompare(v.key, v.derive(password, len(v.key))) != 1 {
+		return ErrMismatch
+	}
+
+	return nil
+}
+
+func (v *argon2idVerifier) String() string {
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2idPrefix, argon2.Version, v.memory, v.time, v.threads,
+		base64.RawStdEncoding.EncodeToString(v.salt), base64.RawStdEncoding.EncodeToString(v.key))
+}
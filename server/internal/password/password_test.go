@@ -0,0 +1,83 @@
+package password
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestHashAndVerify(t *testing.T) {
+	tests := []struct {
+		algorithm Algorithm
+		cost      int
+		prefix    string
+	}{
+		{algorithm: AlgorithmBcrypt, cost: bcrypt.MinCost, prefix: "$2a$04$"},
+		{algorithm: AlgorithmArgon2id, cost: 1, prefix: "$argon2id$v=19$m=19456,t=1,p=1$"},
+		{algorithm: AlgorithmArgon2id, prefix: "$argon2id$v=19$m=19456,t=2,p=1$"},
+	}
+
+	for _, tt := range tests {
+		hash, err := Hash("hunter2", tt.algorithm, tt.cost)
+		assert.NoError(t, err, tt.algorithm)
+		assert.True(t, strings.HasPrefix(hash, tt.prefix), hash)
+		assert.True(t, IsHash(hash), hash)
+
+		assert.NoError(t, Verify(hash, "hunter2"), tt.algorithm)
+		assert.ErrorIs(t, Verify(hash, "hunter3"), ErrMismatch, tt.algorithm)
+		assert.ErrorIs(t, Verify(hash, ""), ErrMismatch, tt.algorithm)
+
+		again, err := Hash("hunter2", tt.algorithm, tt.cost)
+		assert.NoError(t, err)
+		assert.NotEqual(t, hash, again, "hashes must be salted")
+	}
+
+	_, err := Hash("hunter2", Algorithm("md5"), 0)
+	assert.Error(t, err)
+
+	_, err = Hash("", AlgorithmBcrypt, 0)
+	assert.Error(t, err)
+}
+
+func TestArgon2idEncoding(t *testing.T) {
+	hash, err := Hash("hunter2", AlgorithmArgon2id, 1)
+	assert.NoError(t, err)
+
+	v, err := parseArgon2id(hash)
+	assert.NoError(t, err)
+	assert.Equal(t, hash, v.String())
+	assert.Equal(t, uint32(argon2idMemory), v.memory)
+	assert.Equal(t, uint32(1), v.time)
+	assert.Equal(t, uint8(argon2idThreads), v.threads)
+	assert.Len(t, v.salt, argon2idSaltLen)
+	assert.Len(t, v.key, argon2idKeyLen)
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		encoded     string
+		expectError bool
+	}{
+		{encoded: "plaintext"},
+		{encoded: "$2a$04$invalid", expectError: true},
+		{encoded: "$argon2id$v=19$m=19456,t=2,p=1$c2FsdA", expectError: true},
+		{encoded: "$argon2id$v=18$m=19456,t=2,p=1$c2FsdA$a2V5", expectError: true},
+		{encoded: "$argon2id$v=19$m=0,t=2,p=1$c2FsdA$a2V5", expectError: true},
+		{encoded: "$argon2id$v=19$m=19456,t=2,p=1$!!!$a2V5", expectError: true},
+		{encoded: "$argon2id$v=19$m=19456,t=2,p=1$c2FsdA$a2V5"},
+	}
+
+	for _, tt := range tests {
+		_, err := Parse(tt.encoded)
+		if tt.expectError {
+			assert.Error(t, err, tt.encoded)
+		} else {
+			assert.NoError(t, err, tt.encoded)
+		}
+	}
+
+	assert.False(t, IsHash("plaintext"))
+	assert.False(t, IsHash("$notahash"))
+}
@@ -0,0 +1,188 @@
+package stream
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ApexCorse/ephoros/server/internal/db"
+)
+
+var (
+	ErrSlowConsumer = errors.New("slow consumer, buffer full")
+	ErrHubClosed    = errors.New("stream hub closed")
+)
+
+type Event struct {
+	Section string    `json:"section"`
+	Module  string    `json:"module"`
+	Sensor  string    `json:"sensor"`
+	Record  db.Record `json:"record"`
+}
+
+type HubConfig struct {
+	BufferSize int
+}
+
+type HubStats struct {
+	Subscribers  int    `json:"subscribers"`
+	Published    uint64 `json:"published"`
+	Delivered    uint64 `json:"delivered"`
+	Disconnected uint64 `json:"disconnected"`
+}
+
+type Hub struct {
+	bufferSize int
+
+	mu          sync.RWMutex
+	subscribers map[*Subscription]struct{}
+	closed      bool
+
+	published    atomic.Uint64
+	delivered    atomic.Uint64
+	disconnected atomic.Uint64
+}
+
+type Subscription struct {
+	hub       *Hub
+	selectors []Selector
+	events    chan Event
+	done      chan struct{}
+	once      sync.Once
+	err       error
+}
+
+func NewHub(cfg *HubConfig) *Hub {
+	bufferSize := cfg.BufferSize
+	if bufferSize <= 0 {
+		log.Println("[STREAM] Subscriber buffer size not set, using 256")
+		bufferSize = 256
+	}
+
+	log.Printf("[STREAM] Starting stream hub - BufferSize: %d", bufferSize)
+
+	return &Hub{
+		bufferSize:  bufferSize,
+		subscribers: make(map[*Subscription]struct{}),
+	}
+}
+
+func (h *Hub) Subscribe(selectors []Selector) (*Subscription, error) {
+	sub := &Subscription{
+		hub:       h,
+		selectors: selectors,
+		events:    make(chan Event, h.bufferSize),
+		done:      make(chan struct{}),
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return nil, ErrHubClosed
+	}
+
+	h.subscribers[sub] = struct{}{}
+	log.Printf("[STREAM] Subscriber added - Selectors: %d, Subscribers: %d", len(selectors), len(h.subscribers))
+
+	return sub, nil
+}
+
+func (h *Hub) Publish(event Event) {
+	h.published.Add(1)
+
+	var slow []*Subscription
+
+	h.mu.RLock()
+	for sub := range h.subscribers {
+		if !sub.matches(&event) {
+			continue
+		}
+
+		select {
+		case sub.events <- event:
+			h.delivered.Add(1)
+		default:
+			slow = append(slow, sub)
+		}
+	}
+	h.mu.RUnlock()
+
+	for _, sub := range slow {
+		log.Printf("[STREAM] Disconnecting slow subscriber - Buffer: %d/%d", len(sub.events), cap(sub.events))
+		h.disconnected.Add(1)
+		h.remove(sub, ErrSlowConsumer)
+	}
+}
+
+func (h *Hub) Close() {
+	h.mu.Lock()
+	h.closed = true
+	subscribers := make([]*Subscription, 0, len(h.subscribers))
+	for sub := range h.subscribers {
+		subscribers = append(subscribers, sub)
+	}
+	h.mu.Unlock()
+
+	log.Printf("[STREAM] Closing stream hub, disconnecting %d subscribers", len(subscribers))
+
+	for _, sub := range subscribers {
+		h.remove(sub, ErrHubClosed)
+	}
+}
+
+func (h *Hub) Stats() HubStats {
+	h.mu.RLock()
+	subscribers := len(h.subscribers)
+	h.mu.RUnlock()
+
+	return HubStats{
+		Subscribers:  subscribers,
+		Published:    h.published.Load(),
+		Delivered:    h.delivered.Load(),
+		Disconnected: h.disconnected.Load(),
+	}
+}
+
+func (h *Hub) remove(sub *Subscription, err error) {
+	h.mu.Lock()
+	delete(h.subscribers, sub)
+	h.mu.Unlock()
+
+	sub.once.Do(func() {
+		sub.err = err
+		close(sub.done)
+	})
+}
+
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+func (s *Subscription) Done() <-chan struct{} {
+	return s.done
+}
+
+func (s *Subscription) Err() error {
+	select {
+	case <-s.done:
+		return s.err
+	default:
+		return nil
+	}
+}
+
+func (s *Subscription) Close() {
+	s.hub.remove(s, nil)
+}
+
+func (s *Subscription) matches(event *Event) bool {
+	for _, selector := range s.selectors {
+		if selector.Match(event.Section, event.Module, event.Sensor) {
+			return true
+		}
+	}
+
+	return false
+}
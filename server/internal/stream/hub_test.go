@@ -0,0 +1,110 @@
+package stream
+
+import (
+	"testing"
+
+	"github.com/ApexCorse/ephoros/server/internal/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func mustSelectors(t *testing.T, values ...string) []Selector {
+	selectors := make([]Selector, 0, len(values))
+	for _, value := range values {
+		selector, err := ParseSelector(value)
+		if err != nil {
+			t.Fatalf("invalid selector %q: %v", value, err)
+		}
+		selectors = append(selectors, selector)
+	}
+
+	return selectors
+}
+
+func newEvent(section, module, sensor string, value float64) Event {
+	return Event{
+		Section: section,
+		Module:  module,
+		Sensor:  sensor,
+		Record:  db.Record{SensorID: 1, Value: value},
+	}
+}
+
+func TestHub_Publish(t *testing.T) {
+	hub := NewHub(&HubConfig{BufferSize: 4})
+
+	battery, err := hub.Subscribe(mustSelectors(t, "Battery/#"))
+	assert.NoError(t, err)
+	defer battery.Close()
+
+	pumps, err := hub.Subscribe(mustSelectors(t, "Cooling/Pump/Speed", "+/+/Current"))
+	assert.NoError(t, err)
+	defer pumps.Close()
+
+	hub.Publish(newEvent("Battery", "Pack", "Current", 1))
+	hub.Publish(newEvent("Cooling", "Pump", "Speed", 2))
+	hub.Publish(newEvent("Cooling", "Radiator", "Inlet", 3))
+
+	assert.Len(t, battery.Events(), 1)
+	assert.Equal(t, 1.0, (<-battery.Events()).Record.Value)
+
+	assert.Len(t, pumps.Events(), 2)
+	assert.Equal(t, 1.0, (<-pumps.Events()).Record.Value)
+	assert.Equal(t, 2.0, (<-pumps.Events()).Record.Value)
+
+	stats := hub.Stats()
+	assert.Equal(t, 2, stats.Subscribers)
+	assert.Equal(t, uint64(3), stats.Published)
+	assert.Equal(t, uint64(3), stats.Delivered)
+}
+
+func TestHub_SlowConsumer(t *testing.T) {
+	hub := NewHub(&HubConfig{BufferSize: 2})
+
+	slow, err := hub.Subscribe(mustSelectors(t, "#"))
+	assert.NoError(t, err)
+
+	fast, err := hub.Subscribe(mustSelectors(t, "#"))
+	assert.NoError(t, err)
+	defer fast.Close()
+
+	for i := range 3 {
+		hub.Publish(newEvent("Battery", "Pack", "Current", float64(i)))
+		<-fast.Events()
+	}
+
+	select {
+	case <-slow.Done():
+	default:
+		t.Fatal("slow subscriber was not disconnected")
+	}
+	assert.ErrorIs(t, slow.Err(), ErrSlowConsumer)
+	assert.NoError(t, fast.Err())
+
+	stats := hub.Stats()
+	assert.Equal(t, 1, stats.Subscribers)
+	assert.Equal(t, uint64(1), stats.Disconnected)
+
+	hub.Publish(newEvent("Battery", "Pack", "Current", 4))
+	assert.Len(t, slow.Events(), 2)
+}
+
+func TestHub_Close(t *testing.T) {
+	hub := NewHub(&HubConfig{})
+
+	sub, err := hub.Subscribe(mustSelectors(t, "#"))
+	assert.NoError(t, err)
+
+	sub.Close()
+	assert.NoError(t, sub.Err())
+	assert.Equal(t, 0, hub.Stats().Subscribers)
+
+	other, err := hub.Subscribe(mustSelectors(t, "#"))
+	assert.NoError(t, err)
+
+	hub.Close()
+	<-other.Done()
+	assert.ErrorIs(t, other.Err(), ErrHubClosed)
+
+	_, err = hub.Subscribe(mustSelectors(t, "#"))
+	assert.ErrorIs(t, err, ErrHubClosed)
+}
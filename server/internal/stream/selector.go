@@ -0,0 +1,63 @@
+package stream
+
+import (
+	"fmt"
+	"strings"
+)
+
+const maxSelectorLevels = 3
+
+type Selector struct {
+	levels []string
+}
+
+func ParseSelector(s string) (Selector, error) {
+	if s == "" {
+		return Selector{}, fmt.Errorf("empty selector")
+	}
+
+	levels := strings.Split(s, "/")
+	if len(levels) > maxSelectorLevels {
+		return Selector{}, fmt.Errorf("selector %q has %d levels, expected at most section/module/sensor", s, len(levels))
+	}
+
+	for i, level := range levels {
+		switch {
+		case level == "":
+			return Selector{}, fmt.Errorf("selector %q has an empty level", s)
+		case level == "#" && i != len(levels)-1:
+			return Selector{}, fmt.Errorf("selector %q: '#' must be the last level", s)
+		case level != "#" && level != "+" && strings.ContainsAny(level, "+#"):
+			return Selector{}, fmt.Errorf("selector %q: wildcards must occupy a whole level", s)
+		}
+	}
+
+	if len(levels) < maxSelectorLevels && levels[len(levels)-1] != "#" {
+		return Selector{}, fmt.Errorf("selector %q must name section/module/sensor or end with '#'", s)
+	}
+
+	return Selector{levels: levels}, nil
+}
+
+func (s Selector) Match(section, module, sensor string) bool {
+	path := [maxSelectorLevels]string{section, module, sensor}
+
+	for i, level := range s.levels {
+		switch level {
+		case "#":
+			return true
+		case "+":
+			continue
+		default:
+			if level != path[i] {
+				return false
+			}
+		}
+	}
+
+	return len(s.levels) == maxSelectorLevels
+}
+
+func (s Selector) String() string {
+	return strings.Join(s.levels, "/")
+}
@@ -0,0 +1,59 @@
+package stream
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSelector(t *testing.T) {
+	valid := []string{
+		"Battery/Pack/Current",
+		"Battery/+/Current",
+		"+/+/+",
+		"Battery/#",
+		"Battery/Pack/#",
+		"#",
+	}
+	for _, s := range valid {
+		selector, err := ParseSelector(s)
+		assert.NoError(t, err, s)
+		assert.Equal(t, s, selector.String())
+	}
+
+	invalid := []string{
+		"",
+		"Battery",
+		"Battery/Pack",
+		"Battery//Current",
+		"Battery/#/Current",
+		"Battery/Pack+/Current",
+		"Battery/Pack/Current/Extra",
+	}
+	for _, s := range invalid {
+		_, err := ParseSelector(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestSelectorMatch(t *testing.T) {
+	tests := []struct {
+		selector string
+		path     [3]string
+		match    bool
+	}{
+		{"Battery/Pack/Current", [3]string{"Battery", "Pack", "Current"}, true},
+		{"Battery/Pack/Current", [3]string{"Battery", "Pack", "Voltage"}, false},
+		{"Battery/+/Current", [3]string{"Battery", "Module 1", "Current"}, true},
+		{"Battery/+/Current", [3]string{"Cooling", "Module 1", "Current"}, false},
+		{"Battery/#", [3]string{"Battery", "Pack", "Voltage"}, true},
+		{"Battery/Pack/#", [3]string{"Battery", "Module 1", "Voltage"}, false},
+		{"#", [3]string{"Cooling", "Pump", "Speed"}, true},
+	}
+
+	for _, tt := range tests {
+		selector, err := ParseSelector(tt.selector)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.match, selector.Match(tt.path[0], tt.path[1], tt.path[2]), "%s %v", tt.selector, tt.path)
+	}
+}